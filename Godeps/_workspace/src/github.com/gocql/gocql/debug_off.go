@@ -0,0 +1,5 @@
+// +build !gocql_debug
+
+package gocql
+
+const gocqlDebug = false
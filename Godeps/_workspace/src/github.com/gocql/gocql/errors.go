@@ -3,21 +3,25 @@ package gocql
 import "fmt"
 
 const (
-	errServer        = 0x0000
-	errProtocol      = 0x000A
-	errCredentials   = 0x0100
-	errUnavailable   = 0x1000
-	errOverloaded    = 0x1001
-	errBootstrapping = 0x1002
-	errTruncate      = 0x1003
-	errWriteTimeout  = 0x1100
-	errReadTimeout   = 0x1200
-	errSyntax        = 0x2000
-	errUnauthorized  = 0x2100
-	errInvalid       = 0x2200
-	errConfig        = 0x2300
-	errAlreadyExists = 0x2400
-	errUnprepared    = 0x2500
+	errServer          = 0x0000
+	errProtocol        = 0x000A
+	errCredentials     = 0x0100
+	errUnavailable     = 0x1000
+	errOverloaded      = 0x1001
+	errBootstrapping   = 0x1002
+	errTruncate        = 0x1003
+	errWriteTimeout    = 0x1100
+	errReadTimeout     = 0x1200
+	errReadFailure     = 0x1300
+	errFunctionFailure = 0x1400
+	errWriteFailure    = 0x1500
+	errCDCWriteFailure = 0x1600
+	errSyntax          = 0x2000
+	errUnauthorized    = 0x2100
+	errInvalid         = 0x2200
+	errConfig          = 0x2300
+	errAlreadyExists   = 0x2400
+	errUnprepared      = 0x2500
 )
 
 type RequestError interface {
@@ -60,6 +64,8 @@ func (e *RequestErrUnavailable) String() string {
 	return fmt.Sprintf("[request_error_unavailable consistency=%s required=%d alive=%d]", e.Consistency, e.Required, e.Alive)
 }
 
+type ErrorMap map[string]uint16
+
 type RequestErrWriteTimeout struct {
 	errorFrame
 	Consistency Consistency
@@ -68,6 +74,20 @@ type RequestErrWriteTimeout struct {
 	WriteType   string
 }
 
+type RequestErrWriteFailure struct {
+	errorFrame
+	Consistency Consistency
+	Received    int
+	BlockFor    int
+	NumFailures int
+	WriteType   string
+	ErrorMap    ErrorMap
+}
+
+type RequestErrCDCWriteFailure struct {
+	errorFrame
+}
+
 type RequestErrReadTimeout struct {
 	errorFrame
 	Consistency Consistency
@@ -86,3 +106,20 @@ type RequestErrUnprepared struct {
 	errorFrame
 	StatementId []byte
 }
+
+type RequestErrReadFailure struct {
+	errorFrame
+	Consistency Consistency
+	Received    int
+	BlockFor    int
+	NumFailures int
+	DataPresent bool
+	ErrorMap    ErrorMap
+}
+
+type RequestErrFunctionFailure struct {
+	errorFrame
+	Keyspace string
+	Function string
+	ArgTypes []string
+}
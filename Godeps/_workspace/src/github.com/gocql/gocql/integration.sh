@@ -1,6 +1,6 @@
 #!/bin/bash
 
-set -e
+set -eux
 
 function run_tests() {
 	local clusterSize=3
@@ -14,67 +14,74 @@ function run_tests() {
 	local keypath="$(pwd)/testdata/pki"
 
 	local conf=(
-	    "client_encryption_options.enabled: true"
-	    "client_encryption_options.keystore: $keypath/.keystore"
-	    "client_encryption_options.keystore_password: cassandra"
-	    "client_encryption_options.require_client_auth: true"
-	    "client_encryption_options.truststore: $keypath/.truststore"
-	    "client_encryption_options.truststore_password: cassandra"
-	    "concurrent_reads: 2"
-	    "concurrent_writes: 2"
-	    "rpc_server_type: sync"
-	    "rpc_min_threads: 2"
-	    "rpc_max_threads: 2"
-	    "write_request_timeout_in_ms: 5000"
-	    "read_request_timeout_in_ms: 5000"
+		"client_encryption_options.enabled: true"
+		"client_encryption_options.keystore: $keypath/.keystore"
+		"client_encryption_options.keystore_password: cassandra"
+		"client_encryption_options.require_client_auth: true"
+		"client_encryption_options.truststore: $keypath/.truststore"
+		"client_encryption_options.truststore_password: cassandra"
+		"concurrent_reads: 2"
+		"concurrent_writes: 2"
+		"rpc_server_type: sync"
+		"rpc_min_threads: 2"
+		"rpc_max_threads: 2"
+		"write_request_timeout_in_ms: 5000"
+		"read_request_timeout_in_ms: 5000"
 	)
 
 	ccm remove test || true
 
-	ccm create test -v binary:$version -n $clusterSize -d --vnodes --jvm_arg="-Xmx256m -XX:NewSize=100m"
-    ccm updateconf "${conf[@]}"
+	ccm create test -v $version -n $clusterSize -d --vnodes --jvm_arg="-Xmx256m -XX:NewSize=100m"
+	ccm updateconf "${conf[@]}"
 
-    if [ "$auth" = true ]
+	if [ "$auth" = true ]
 	then
 		ccm updateconf 'authenticator: PasswordAuthenticator' 'authorizer: CassandraAuthorizer'
 		rm -rf $HOME/.ccm/test/node1/data/system_auth
 	fi
 
-	ccm start -v
-	ccm status
-	ccm node1 nodetool status
-
 	local proto=2
 	if [[ $version == 1.2.* ]]; then
 		proto=1
+	elif [[ $version == 2.0.* ]]; then
+		proto=2
 	elif [[ $version == 2.1.* ]]; then
 		proto=3
+	elif [[ $version == 2.2.* || $version == 3.0.* ]]; then
+		proto=4
+		ccm updateconf 'enable_user_defined_functions: true'
+		export JVM_EXTRA_OPTS=" -Dcassandra.test.fail_writes_ks=test -Dcassandra.custom_query_handler_class=org.apache.cassandra.cql3.CustomPayloadMirroringQueryHandler"
+	elif [[ $version == 3.*.* ]]; then
+		proto=5
+		ccm updateconf 'enable_user_defined_functions: true'
+		export JVM_EXTRA_OPTS=" -Dcassandra.test.fail_writes_ks=test -Dcassandra.custom_query_handler_class=org.apache.cassandra.cql3.CustomPayloadMirroringQueryHandler"
 	fi
 
+	sleep 1s
+
+	ccm list
+	ccm start --wait-for-binary-proto
+	ccm status
+	ccm node1 nodetool status
+
+	local args="-gocql.timeout=60s -runssl -proto=$proto -rf=3 -clusterSize=$clusterSize -autowait=2000ms -compressor=snappy -gocql.cversion=$version -cluster=$(ccm liveset) ./..."
+
+	go test -v -tags unit -race
+
 	if [ "$auth" = true ]
 	then
-    	sleep 30s
-    	go test -v . -timeout 15s -run=TestAuthentication -tags integration -runssl -runauth -proto=$proto -cluster=$(ccm liveset) -clusterSize=$clusterSize -autowait=1000ms
+		sleep 30s
+		go test -run=TestAuthentication -tags "integration gocql_debug" -timeout=15s -runauth $args
 	else
+		sleep 1s
+		go test -tags "cassandra gocql_debug" -timeout=5m -race $args
+		go test -tags "integration gocql_debug" -timeout=5m -race $args
 
-		go test -timeout 5m -tags integration -cover -v -runssl -proto=$proto -rf=3 -cluster=$(ccm liveset) -clusterSize=$clusterSize -autowait=2000ms -compressor=snappy ./... | tee results.txt
-
-		if [ ${PIPESTATUS[0]} -ne 0 ]; then
-			echo "--- FAIL: ccm status follows:"
-			ccm status
-			ccm node1 nodetool status
-			ccm node1 showlog > status.log
-			cat status.log
-			echo "--- FAIL: Received a non-zero exit code from the go test execution, please investigate this"
-			exit 1
-		fi
-
-		cover=`cat results.txt | grep coverage: | grep -o "[0-9]\{1,3\}" | head -n 1`
+		ccm clear
+		ccm start
+		sleep 1s
 
-		if [[ $cover -lt "55" ]]; then
-			echo "--- FAIL: expected coverage of at least 60 %, but coverage was $cover %"
-			exit 1
-		fi
+		go test -tags "ccm gocql_debug" -timeout=5m -race $args
 	fi
 
 	ccm remove
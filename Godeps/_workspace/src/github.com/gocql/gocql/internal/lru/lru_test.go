@@ -1,4 +1,5 @@
 /*
+Copyright 2015 To gocql authors
 Copyright 2013 Google Inc.
 
 Licensed under the Apache License, Version 2.0 (the "License");
@@ -32,16 +33,14 @@ type complexStruct struct {
 
 var getTests = []struct {
 	name       string
-	keyToAdd   interface{}
-	keyToGet   interface{}
+	keyToAdd   string
+	keyToGet   string
 	expectedOk bool
 }{
-	{"string_hit", "myKey", "myKey", true},
-	{"string_miss", "myKey", "nonsense", false},
-	{"simple_struct_hit", simpleStruct{1, "two"}, simpleStruct{1, "two"}, true},
-	{"simeple_struct_miss", simpleStruct{1, "two"}, simpleStruct{0, "noway"}, false},
-	{"complex_struct_hit", complexStruct{1, simpleStruct{2, "three"}},
-		complexStruct{1, simpleStruct{2, "three"}}, true},
+	{"string_hit", "mystring", "mystring", true},
+	{"string_miss", "mystring", "nonsense", false},
+	{"simple_struct_hit", "two", "two", true},
+	{"simeple_struct_miss", "two", "noway", false},
 }
 
 func TestGet(t *testing.T) {
@@ -59,15 +58,15 @@ func TestGet(t *testing.T) {
 
 func TestRemove(t *testing.T) {
 	lru := New(0)
-	lru.Add("myKey", 1234)
-	if val, ok := lru.Get("myKey"); !ok {
+	lru.Add("mystring", 1234)
+	if val, ok := lru.Get("mystring"); !ok {
 		t.Fatal("TestRemove returned no match")
 	} else if val != 1234 {
 		t.Fatalf("TestRemove failed.  Expected %d, got %v", 1234, val)
 	}
 
-	lru.Remove("myKey")
-	if _, ok := lru.Get("myKey"); ok {
+	lru.Remove("mystring")
+	if _, ok := lru.Get("mystring"); ok {
 		t.Fatal("TestRemove returned a removed entry")
 	}
 }
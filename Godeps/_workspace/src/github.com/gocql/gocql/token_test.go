@@ -5,43 +5,16 @@
 package gocql
 
 import (
+	"bytes"
+	"fmt"
 	"math/big"
+	"net"
+	"sort"
 	"strconv"
 	"testing"
 )
 
-func TestMurmur3H1(t *testing.T) {
-	assertMurmur3H1(t, []byte{}, 0x000000000000000)
-	assertMurmur3H1(t, []byte{0}, 0x4610abe56eff5cb5)
-	assertMurmur3H1(t, []byte{0, 1}, 0x7cb3f5c58dab264c)
-	assertMurmur3H1(t, []byte{0, 1, 2}, 0xb872a12fef53e6be)
-	assertMurmur3H1(t, []byte{0, 1, 2, 3}, 0xe1c594ae0ddfaf10)
-	assertMurmur3H1(t, []byte("hello"), 0xcbd8a7b341bd9b02)
-	assertMurmur3H1(t, []byte("hello, world"), 0x342fac623a5ebc8e)
-	assertMurmur3H1(t, []byte("19 Jan 2038 at 3:14:07 AM"), 0xb89e5988b737affc)
-	assertMurmur3H1(t, []byte("The quick brown fox jumps over the lazy dog."), 0xcd99481f9ee902c9)
-}
-
-func assertMurmur3H1(t *testing.T, data []byte, expected uint64) {
-	actual := murmur3H1(data)
-	if actual != expected {
-		t.Errorf("Expected h1 = %x for data = %x, but was %x", expected, data, actual)
-	}
-}
-
-func BenchmarkMurmur3H1(b *testing.B) {
-	var h1 uint64
-	var data [1024]byte
-	for i := 0; i < 1024; i++ {
-		data[i] = byte(i)
-	}
-	for i := 0; i < b.N; i++ {
-		b.ResetTimer()
-		h1 = murmur3H1(data[:])
-		_ = murmur3Token(int64(h1))
-	}
-}
-
+// Tests of the murmur3Patitioner
 func TestMurmur3Partitioner(t *testing.T) {
 	token := murmur3Partitioner{}.ParseString("-1053604476080545076")
 
@@ -58,6 +31,7 @@ func TestMurmur3Partitioner(t *testing.T) {
 	}
 }
 
+// Tests of the murmur3Token
 func TestMurmur3Token(t *testing.T) {
 	if murmur3Token(42).Less(murmur3Token(42)) {
 		t.Errorf("Expected Less to return false, but was true")
@@ -70,38 +44,80 @@ func TestMurmur3Token(t *testing.T) {
 	}
 }
 
-func TestOrderPreservingPartitioner(t *testing.T) {
+// Tests of the orderedPartitioner
+func TestOrderedPartitioner(t *testing.T) {
 	// at least verify that the partitioner
 	// doesn't return nil
+	p := orderedPartitioner{}
 	pk, _ := marshalInt(nil, 1)
-	token := orderPreservingPartitioner{}.Hash(pk)
+	token := p.Hash(pk)
 	if token == nil {
 		t.Fatal("token was nil")
 	}
+
+	str := token.String()
+	parsedToken := p.ParseString(str)
+
+	if !bytes.Equal([]byte(token.(orderedToken)), []byte(parsedToken.(orderedToken))) {
+		t.Errorf("Failed to convert to and from a string %s expected %x but was %x",
+			str,
+			[]byte(token.(orderedToken)),
+			[]byte(parsedToken.(orderedToken)),
+		)
+	}
 }
 
-func TestOrderPreservingToken(t *testing.T) {
-	if orderPreservingToken([]byte{0, 0, 4, 2}).Less(orderPreservingToken([]byte{0, 0, 4, 2})) {
+// Tests of the orderedToken
+func TestOrderedToken(t *testing.T) {
+	if orderedToken([]byte{0, 0, 4, 2}).Less(orderedToken([]byte{0, 0, 4, 2})) {
 		t.Errorf("Expected Less to return false, but was true")
 	}
-	if !orderPreservingToken([]byte{0, 0, 3}).Less(orderPreservingToken([]byte{0, 0, 4, 2})) {
+	if !orderedToken([]byte{0, 0, 3}).Less(orderedToken([]byte{0, 0, 4, 2})) {
 		t.Errorf("Expected Less to return true, but was false")
 	}
-	if orderPreservingToken([]byte{0, 0, 4, 2}).Less(orderPreservingToken([]byte{0, 0, 3})) {
+	if orderedToken([]byte{0, 0, 4, 2}).Less(orderedToken([]byte{0, 0, 3})) {
 		t.Errorf("Expected Less to return false, but was true")
 	}
 }
 
+// Tests of the randomPartitioner
 func TestRandomPartitioner(t *testing.T) {
 	// at least verify that the partitioner
 	// doesn't return nil
+	p := randomPartitioner{}
 	pk, _ := marshalInt(nil, 1)
-	token := randomPartitioner{}.Hash(pk)
+	token := p.Hash(pk)
 	if token == nil {
 		t.Fatal("token was nil")
 	}
+
+	str := token.String()
+	parsedToken := p.ParseString(str)
+
+	if (*big.Int)(token.(*randomToken)).Cmp((*big.Int)(parsedToken.(*randomToken))) != 0 {
+		t.Errorf("Failed to convert to and from a string %s expected %v but was %v",
+			str,
+			token,
+			parsedToken,
+		)
+	}
 }
 
+func TestRandomPartitionerMatchesReference(t *testing.T) {
+	// example taken from datastax python driver
+	//    >>> from cassandra.metadata import MD5Token
+	//    >>> MD5Token.hash_fn("test")
+	//    12707736894140473154801792860916528374L
+	var p randomPartitioner
+	expect := "12707736894140473154801792860916528374"
+	actual := p.Hash([]byte("test")).String()
+	if actual != expect {
+		t.Errorf("expected random partitioner to generate tokens in the same way as the reference"+
+			" python client. Expected %s, but got %s", expect, actual)
+	}
+}
+
+// Tests of the randomToken
 func TestRandomToken(t *testing.T) {
 	if ((*randomToken)(big.NewInt(42))).Less((*randomToken)(big.NewInt(42))) {
 		t.Errorf("Expected Less to return false, but was true")
@@ -116,74 +132,193 @@ func TestRandomToken(t *testing.T) {
 
 type intToken int
 
-func (i intToken) String() string {
-	return strconv.Itoa(int(i))
-}
-
-func (i intToken) Less(token token) bool {
-	return i < token.(intToken)
-}
+func (i intToken) String() string        { return strconv.Itoa(int(i)) }
+func (i intToken) Less(token token) bool { return i < token.(intToken) }
 
-func TestIntTokenRing(t *testing.T) {
-	// test based on example at the start of this page of documentation:
-	// http://www.datastax.com/docs/0.8/cluster_architecture/partitioning
+// Test of the token ring implementation based on example at the start of this
+// page of documentation:
+// http://www.datastax.com/docs/0.8/cluster_architecture/partitioning
+func TestTokenRing_Int(t *testing.T) {
 	host0 := &HostInfo{}
 	host25 := &HostInfo{}
 	host50 := &HostInfo{}
 	host75 := &HostInfo{}
-	tokenRing := &tokenRing{
+	ring := &tokenRing{
 		partitioner: nil,
-		tokens: []token{
-			intToken(0),
-			intToken(25),
-			intToken(50),
-			intToken(75),
-		},
-		hosts: []*HostInfo{
-			host0,
-			host25,
-			host50,
-			host75,
+		// these tokens and hosts are out of order to test sorting
+		tokens: []hostToken{
+			{intToken(0), host0},
+			{intToken(50), host50},
+			{intToken(75), host75},
+			{intToken(25), host25},
 		},
 	}
 
-	if tokenRing.GetHostForToken(intToken(0)) != host0 {
+	sort.Sort(ring)
+
+	if ring.GetHostForToken(intToken(0)) != host0 {
 		t.Error("Expected host 0 for token 0")
 	}
-	if tokenRing.GetHostForToken(intToken(1)) != host25 {
+	if ring.GetHostForToken(intToken(1)) != host25 {
 		t.Error("Expected host 25 for token 1")
 	}
-	if tokenRing.GetHostForToken(intToken(24)) != host25 {
+	if ring.GetHostForToken(intToken(24)) != host25 {
 		t.Error("Expected host 25 for token 24")
 	}
-	if tokenRing.GetHostForToken(intToken(25)) != host25 {
+	if ring.GetHostForToken(intToken(25)) != host25 {
 		t.Error("Expected host 25 for token 25")
 	}
-	if tokenRing.GetHostForToken(intToken(26)) != host50 {
+	if ring.GetHostForToken(intToken(26)) != host50 {
 		t.Error("Expected host 50 for token 26")
 	}
-	if tokenRing.GetHostForToken(intToken(49)) != host50 {
+	if ring.GetHostForToken(intToken(49)) != host50 {
 		t.Error("Expected host 50 for token 49")
 	}
-	if tokenRing.GetHostForToken(intToken(50)) != host50 {
+	if ring.GetHostForToken(intToken(50)) != host50 {
 		t.Error("Expected host 50 for token 50")
 	}
-	if tokenRing.GetHostForToken(intToken(51)) != host75 {
+	if ring.GetHostForToken(intToken(51)) != host75 {
 		t.Error("Expected host 75 for token 51")
 	}
-	if tokenRing.GetHostForToken(intToken(74)) != host75 {
+	if ring.GetHostForToken(intToken(74)) != host75 {
 		t.Error("Expected host 75 for token 74")
 	}
-	if tokenRing.GetHostForToken(intToken(75)) != host75 {
+	if ring.GetHostForToken(intToken(75)) != host75 {
 		t.Error("Expected host 75 for token 75")
 	}
-	if tokenRing.GetHostForToken(intToken(76)) != host0 {
+	if ring.GetHostForToken(intToken(76)) != host0 {
 		t.Error("Expected host 0 for token 76")
 	}
-	if tokenRing.GetHostForToken(intToken(99)) != host0 {
+	if ring.GetHostForToken(intToken(99)) != host0 {
 		t.Error("Expected host 0 for token 99")
 	}
-	if tokenRing.GetHostForToken(intToken(100)) != host0 {
+	if ring.GetHostForToken(intToken(100)) != host0 {
 		t.Error("Expected host 0 for token 100")
 	}
 }
+
+// Test for the behavior of a nil pointer to tokenRing
+func TestTokenRing_Nil(t *testing.T) {
+	var ring *tokenRing = nil
+
+	if ring.GetHostForToken(nil) != nil {
+		t.Error("Expected nil for nil token ring")
+	}
+	if ring.GetHostForPartitionKey(nil) != nil {
+		t.Error("Expected nil for nil token ring")
+	}
+}
+
+// Test of the recognition of the partitioner class
+func TestTokenRing_UnknownPartition(t *testing.T) {
+	_, err := newTokenRing("UnknownPartitioner", nil)
+	if err == nil {
+		t.Error("Expected error for unknown partitioner value, but was nil")
+	}
+}
+
+func hostsForTests(n int) []*HostInfo {
+	hosts := make([]*HostInfo, n)
+	for i := 0; i < n; i++ {
+		host := &HostInfo{
+			connectAddress: net.IPv4(1, 1, 1, byte(n)),
+			tokens:         []string{fmt.Sprintf("%d", n)},
+		}
+
+		hosts[i] = host
+	}
+	return hosts
+}
+
+// Test of the tokenRing with the Murmur3Partitioner
+func TestTokenRing_Murmur3(t *testing.T) {
+	// Note, strings are parsed directly to int64, they are not murmur3 hashed
+	hosts := hostsForTests(4)
+	ring, err := newTokenRing("Murmur3Partitioner", hosts)
+	if err != nil {
+		t.Fatalf("Failed to create token ring due to error: %v", err)
+	}
+
+	p := murmur3Partitioner{}
+
+	for _, host := range hosts {
+		actual := ring.GetHostForToken(p.ParseString(host.tokens[0]))
+		if !actual.ConnectAddress().Equal(host.ConnectAddress()) {
+			t.Errorf("Expected address %v for token %q, but was %v", host.ConnectAddress(),
+				host.tokens[0], actual.ConnectAddress())
+		}
+	}
+
+	actual := ring.GetHostForToken(p.ParseString("12"))
+	if !actual.ConnectAddress().Equal(hosts[1].ConnectAddress()) {
+		t.Errorf("Expected address 1 for token \"12\", but was %s", actual.ConnectAddress())
+	}
+
+	actual = ring.GetHostForToken(p.ParseString("24324545443332"))
+	if !actual.ConnectAddress().Equal(hosts[0].ConnectAddress()) {
+		t.Errorf("Expected address 0 for token \"24324545443332\", but was %s", actual.ConnectAddress())
+	}
+}
+
+// Test of the tokenRing with the OrderedPartitioner
+func TestTokenRing_Ordered(t *testing.T) {
+	// Tokens here more or less are similar layout to the int tokens above due
+	// to each numeric character translating to a consistently offset byte.
+	hosts := hostsForTests(4)
+	ring, err := newTokenRing("OrderedPartitioner", hosts)
+	if err != nil {
+		t.Fatalf("Failed to create token ring due to error: %v", err)
+	}
+
+	p := orderedPartitioner{}
+
+	var actual *HostInfo
+	for _, host := range hosts {
+		actual = ring.GetHostForToken(p.ParseString(host.tokens[0]))
+		if !actual.ConnectAddress().Equal(host.ConnectAddress()) {
+			t.Errorf("Expected address %v for token %q, but was %v", host.ConnectAddress(),
+				host.tokens[0], actual.ConnectAddress())
+		}
+	}
+
+	actual = ring.GetHostForToken(p.ParseString("12"))
+	if !actual.peer.Equal(hosts[1].peer) {
+		t.Errorf("Expected address 1 for token \"12\", but was %s", actual.ConnectAddress())
+	}
+
+	actual = ring.GetHostForToken(p.ParseString("24324545443332"))
+	if !actual.ConnectAddress().Equal(hosts[1].ConnectAddress()) {
+		t.Errorf("Expected address 1 for token \"24324545443332\", but was %s", actual.ConnectAddress())
+	}
+}
+
+// Test of the tokenRing with the RandomPartitioner
+func TestTokenRing_Random(t *testing.T) {
+	// String tokens are parsed into big.Int in base 10
+	hosts := hostsForTests(4)
+	ring, err := newTokenRing("RandomPartitioner", hosts)
+	if err != nil {
+		t.Fatalf("Failed to create token ring due to error: %v", err)
+	}
+
+	p := randomPartitioner{}
+
+	var actual *HostInfo
+	for _, host := range hosts {
+		actual = ring.GetHostForToken(p.ParseString(host.tokens[0]))
+		if !actual.ConnectAddress().Equal(host.ConnectAddress()) {
+			t.Errorf("Expected address %v for token %q, but was %v", host.ConnectAddress(),
+				host.tokens[0], actual.ConnectAddress())
+		}
+	}
+
+	actual = ring.GetHostForToken(p.ParseString("12"))
+	if !actual.peer.Equal(hosts[1].peer) {
+		t.Errorf("Expected address 1 for token \"12\", but was %s", actual.ConnectAddress())
+	}
+
+	actual = ring.GetHostForToken(p.ParseString("24324545443332"))
+	if !actual.ConnectAddress().Equal(hosts[0].ConnectAddress()) {
+		t.Errorf("Expected address 1 for token \"24324545443332\", but was %s", actual.ConnectAddress())
+	}
+}
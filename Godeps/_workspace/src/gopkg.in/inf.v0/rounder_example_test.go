@@ -5,7 +5,7 @@ import (
 	"os"
 	"text/tabwriter"
 
-	"speter.net/go/exp/math/dec/inf"
+	"gopkg.in/inf.v0"
 )
 
 // This example displays the results of Dec.Round with each of the Rounders.
@@ -31,7 +31,8 @@ func ExampleRounder() {
 		{"RoundHalfEven", inf.RoundHalfEven}, {"RoundExact", inf.RoundExact},
 	}
 
-	fmt.Println("The results of new(inf.Dec).Round(x, s, inf.RoundXXX):\n")
+	fmt.Println("The results of new(inf.Dec).Round(x, s, inf.RoundXXX):")
+	fmt.Println()
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
 	fmt.Fprint(w, "x\ts\t|\t")
 	for _, r := range rounders {
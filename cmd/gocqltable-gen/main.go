@@ -0,0 +1,361 @@
+// Command gocqltable-gen generates row mappers for row structs, which Table and
+// recipes.CRUD use to read and write rows without reflection. It is meant to
+// be run by go generate in the package of the structs:
+//
+//	//go:generate gocqltable-gen -type=User,Tweet
+//
+// The mappers are written to user_mapper.go, named after the first type,
+// unless another file is given with -output. They must be regenerated when
+// the structs change.
+//
+// Embedded and inline structs are flattened like the reflect package does.
+// Struct types registered with RegisterType or RegisterColumnType are only
+// known to the program that registers them, so they must also be listed with
+// -columns for their fields to be kept as single columns:
+//
+//	//go:generate gocqltable-gen -type=User -columns=example.com/money.Money
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+const generatedBy = "// Code generated by gocqltable-gen. DO NOT EDIT."
+
+var (
+	typeNames = flag.String("type", "", "comma separated list of row struct types; required")
+	output    = flag.String("output", "", "output file name; default <type>_mapper.go")
+	columns   = flag.String("columns", "", "comma separated list of struct types, as path.Name, registered with RegisterType or RegisterColumnType")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gocqltable-gen: ")
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+	if *columns != "" {
+		for _, column := range strings.Split(*columns, ",") {
+			column = strings.TrimSpace(column)
+			i := strings.LastIndex(column, ".")
+			if i < 0 {
+				log.Fatalf("column type %s is not of the form path.Name", column)
+			}
+			r.RegisterColumnTypeName(column[:i], column[i+1:])
+		}
+	}
+
+	src, err := generate(dir, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	file := *output
+	if file == "" {
+		file = strings.ToLower(names[0]) + "_mapper.go"
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, file), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the source of the row mappers of the named struct types of
+// the package in dir.
+func generate(dir string, names []string) ([]byte, error) {
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, imports: make(map[string]string)}
+	var body bytes.Buffer
+	for _, name := range names {
+		if err := g.mapper(&body, strings.TrimSpace(name)); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\npackage %s\n\nimport (\n", generatedBy, pkg.Name())
+	fmt.Fprintf(&buf, "\t%q\n", "github.com/kristoiv/gocqltable")
+	fmt.Fprintf(&buf, "\tcqlreflect %q\n", "github.com/kristoiv/gocqltable/reflect")
+	for path, name := range g.imports {
+		fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+	}
+	fmt.Fprintf(&buf, ")\n\nfunc init() {\n")
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		fmt.Fprintf(&buf, "\tgocqltable.RegisterRowMapper(%s{}, %s{})\n", name, mapperName(name))
+	}
+	fmt.Fprintf(&buf, "}\n")
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source: %v\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// loadPackage type checks the package in dir, leaving out tests and mappers
+// generated earlier.
+func loadPackage(dir string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, p := range pkgs {
+		for _, file := range p.Files {
+			if !isGenerated(file) {
+				files = append(files, file)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	return config.Check(files[0].Name.Name, fset, files, nil)
+}
+
+func isGenerated(file *ast.File) bool {
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if comment.Text == generatedBy {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string // path to name of the packages the mappers use
+}
+
+// field is a field of a row struct, which may be a field of an embedded
+// struct.
+type field struct {
+	info r.FieldInfo
+	path []step
+	typ  types.Type
+}
+
+// step is a field selector on the path to a field.
+type step struct {
+	name string
+	ptr  *types.Pointer // set for pointers to embedded structs
+}
+
+func mapperName(name string) string {
+	return strings.ToLower(name[:1]) + name[1:] + "RowMapper"
+}
+
+func (g *generator) mapper(w *bytes.Buffer, name string) error {
+	obj := g.pkg.Scope().Lookup(name)
+	if obj == nil {
+		return fmt.Errorf("type %s not found in package %s", name, g.pkg.Name())
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("type %s is not a struct", name)
+	}
+	fields, err := g.fields(st, nil, nil, nil, []types.Type{obj.Type()})
+	if err != nil {
+		return fmt.Errorf("type %s: %v", name, err)
+	}
+	keys := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if keys[f.info.Key] {
+			return fmt.Errorf("Duplicated key '%s' in struct %s", f.info.Key, name)
+		}
+		keys[f.info.Key] = true
+	}
+
+	mapper := mapperName(name)
+	fmt.Fprintf(w, "\n// %s maps %s rows without reflection.\n", mapper, name)
+	fmt.Fprintf(w, "type %s struct{}\n", mapper)
+
+	fmt.Fprintf(w, "\nfunc (%s) Fields() []cqlreflect.FieldInfo {\n\treturn []cqlreflect.FieldInfo{\n", mapper)
+	for _, f := range fields {
+		fmt.Fprintf(w, "\t\t%s,\n", fieldInfoLiteral(f.info))
+	}
+	fmt.Fprintf(w, "\t}\n}\n")
+
+	fmt.Fprintf(w, "\nfunc (%s) New() interface{} {\n\treturn new(%s)\n}\n", mapper, name)
+
+	fmt.Fprintf(w, "\nfunc (%s) Values(row interface{}) []interface{} {\n", mapper)
+	fmt.Fprintf(w, "\tv, ok := row.(*%s)\n\tif !ok {\n\t\tvalue := row.(%s)\n\t\tv = &value\n\t}\n", name, name)
+	fmt.Fprintf(w, "\tvalues := make([]interface{}, %d)\n", len(fields))
+	for i, f := range fields {
+		selector := "v." + selectorOf(f.path)
+		var checks []string
+		for j, s := range f.path {
+			if s.ptr != nil {
+				checks = append(checks, fmt.Sprintf("v.%s != nil", selectorOf(f.path[:j+1])))
+			}
+		}
+		if len(checks) == 0 {
+			fmt.Fprintf(w, "\tvalues[%d] = %s\n", i, selector)
+			continue
+		}
+		fmt.Fprintf(w, "\tif %s {\n\t\tvalues[%d] = %s\n\t} else {\n\t\tvalues[%d] = *new(%s)\n\t}\n",
+			strings.Join(checks, " && "), i, selector, i, g.typeString(f.typ))
+	}
+	fmt.Fprintf(w, "\treturn values\n}\n")
+
+	fmt.Fprintf(w, "\nfunc (%s) Pointers(row interface{}) []interface{} {\n", mapper)
+	fmt.Fprintf(w, "\tv := row.(*%s)\n", name)
+	allocated := make(map[string]bool)
+	for _, f := range fields {
+		for j, s := range f.path {
+			selector := selectorOf(f.path[:j+1])
+			if s.ptr == nil || allocated[selector] {
+				continue
+			}
+			allocated[selector] = true
+			fmt.Fprintf(w, "\tif v.%s == nil {\n\t\tv.%s = new(%s)\n\t}\n", selector, selector, g.typeString(s.ptr.Elem()))
+		}
+	}
+	fmt.Fprintf(w, "\treturn []interface{}{\n")
+	for _, f := range fields {
+		fmt.Fprintf(w, "\t\t&v.%s,\n", selectorOf(f.path))
+	}
+	fmt.Fprintf(w, "\t}\n}\n")
+	return nil
+}
+
+// fields returns the fields of st like the reflect package finds them,
+// flattening embedded and inline structs, but leaving out unexported fields.
+func (g *generator) fields(st *types.Struct, index []int, path []step, fields []field, parents []types.Type) ([]field, error) {
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		info, inline, skip := r.ParseTag(v.Name(), reflect.StructTag(st.Tag(i)), v.Embedded())
+		if skip {
+			continue
+		}
+		info.Index = append(append([]int{}, index...), i)
+
+		if inline {
+			s := step{name: v.Name()}
+			ft := v.Type()
+			if p, ok := ft.(*types.Pointer); ok {
+				s.ptr = p
+				ft = p.Elem()
+			}
+			if inner, ok := ft.Underlying().(*types.Struct); ok && !containsType(parents, ft) && !isColumnStruct(ft, inner) {
+				if !v.Exported() && v.Pkg() != g.pkg {
+					return nil, fmt.Errorf("can not access embedded %s of another package", v.Name())
+				}
+				var err error
+				innerPath := append(append([]step{}, path...), s)
+				if fields, err = g.fields(inner, info.Index, innerPath, fields, append(parents, ft)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		if !v.Exported() {
+			continue
+		}
+		fieldPath := append(append([]step{}, path...), step{name: v.Name()})
+		fields = append(fields, field{info: info, path: fieldPath, typ: v.Type()})
+	}
+	return fields, nil
+}
+
+// isColumnStruct reports whether the struct type t, whose underlying struct is
+// st, is stored in a single column, see reflect.IsColumnStruct.
+func isColumnStruct(t types.Type, st *types.Struct) bool {
+	var path, name string
+	if named, ok := t.(*types.Named); ok {
+		name = named.Obj().Name()
+		if pkg := named.Obj().Pkg(); pkg != nil {
+			path = pkg.Path()
+		}
+	}
+	marshaler := types.NewMethodSet(types.NewPointer(t)).Lookup(nil, "MarshalCQL") != nil
+	exported := false
+	for i := 0; i < st.NumFields(); i++ {
+		exported = exported || st.Field(i).Exported()
+	}
+	return r.IsColumnStruct(path, name, marshaler, exported)
+}
+
+func containsType(parents []types.Type, t types.Type) bool {
+	for _, parent := range parents {
+		if types.Identical(parent, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func selectorOf(path []step) string {
+	names := make([]string, len(path))
+	for i, s := range path {
+		names[i] = s.name
+	}
+	return strings.Join(names, ".")
+}
+
+// typeString returns the type expression of t in the generated file, adding
+// the imports it needs.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+func fieldInfoLiteral(info r.FieldInfo) string {
+	index := make([]string, len(info.Index))
+	for i, x := range info.Index {
+		index[i] = fmt.Sprint(x)
+	}
+	s := fmt.Sprintf("{Key: %q, Index: []int{%s}", info.Key, strings.Join(index, ", "))
+	if info.Type != "" {
+		s += fmt.Sprintf(", Type: %q", info.Type)
+	}
+	if info.Tuple {
+		s += ", Tuple: true"
+	}
+	if info.OmitEmpty {
+		s += ", OmitEmpty: true"
+	}
+	if info.ReadOnly {
+		s += ", ReadOnly: true"
+	}
+	return s + "}"
+}
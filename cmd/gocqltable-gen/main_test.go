@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"go/types"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kristoiv/gocqltable"
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+	}{
+		{"User", "testdata/rows/user_mapper.go"},
+		{"Event", "testdata/rows/event_mapper.go"},
+	}
+	for _, test := range tests {
+		src, err := generate("testdata/rows", []string{test.name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden, err := ioutil.ReadFile(test.golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(src, golden) {
+			t.Errorf("generated mapper differs from %s:\n%s", test.golden, src)
+		}
+	}
+}
+
+// event is testdata/rows.Event, which the reflect package maps to the columns
+// ID, Time and Date.
+type event struct {
+	ID int
+	time.Time
+	gocqltable.Date `cql:",inline"`
+}
+
+func TestGenerateFieldsMatchReflect(t *testing.T) {
+	pkg, err := loadPackage("testdata/rows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &generator{pkg: pkg, imports: make(map[string]string)}
+	obj := pkg.Scope().Lookup("Event")
+	fields, err := g.fields(obj.Type().Underlying().(*types.Struct), nil, nil, nil, []types.Type{obj.Type()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []r.FieldInfo
+	for _, f := range fields {
+		got = append(got, f.info)
+	}
+	expected, ok := r.Fields(event{})
+	if !ok {
+		t.Fatal("expected event to be a valid row struct")
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v but got %v", expected, got)
+	}
+}
+
+func TestGenerateMissingType(t *testing.T) {
+	_, err := generate("testdata/rows", []string{"Missing"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a type not found error but got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Code generated by gocqltable-gen. DO NOT EDIT.
+
+package rows
+
+import (
+	"github.com/kristoiv/gocqltable"
+	cqlreflect "github.com/kristoiv/gocqltable/reflect"
+)
+
+func init() {
+	gocqltable.RegisterRowMapper(Event{}, eventRowMapper{})
+}
+
+// eventRowMapper maps Event rows without reflection.
+type eventRowMapper struct{}
+
+func (eventRowMapper) Fields() []cqlreflect.FieldInfo {
+	return []cqlreflect.FieldInfo{
+		{Key: "ID", Index: []int{0}},
+		{Key: "Time", Index: []int{1}},
+		{Key: "Date", Index: []int{2}},
+	}
+}
+
+func (eventRowMapper) New() interface{} {
+	return new(Event)
+}
+
+func (eventRowMapper) Values(row interface{}) []interface{} {
+	v, ok := row.(*Event)
+	if !ok {
+		value := row.(Event)
+		v = &value
+	}
+	values := make([]interface{}, 3)
+	values[0] = v.ID
+	values[1] = v.Time
+	values[2] = v.Date
+	return values
+}
+
+func (eventRowMapper) Pointers(row interface{}) []interface{} {
+	v := row.(*Event)
+	return []interface{}{
+		&v.ID,
+		&v.Time,
+		&v.Date,
+	}
+}
@@ -0,0 +1,38 @@
+package rows
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"github.com/kristoiv/gocqltable"
+)
+
+//go:generate gocqltable-gen -type=User
+//go:generate gocqltable-gen -type=Event
+
+type Audit struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time `cql:"updated_at"`
+}
+
+type Position struct {
+	Lat, Long float64
+}
+
+type User struct {
+	ID       gocql.UUID `cql:"id,type=timeuuid"`
+	Email    string
+	Password string `cql:"-"`
+	Visits   int64  `cql:"visits,readonly"`
+	Nickname string `cql:",omitempty"`
+	Audit
+	*Position
+	secret string
+}
+
+type Event struct {
+	ID int
+	time.Time
+	gocqltable.Date `cql:",inline"`
+}
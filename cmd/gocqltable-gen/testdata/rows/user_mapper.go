@@ -0,0 +1,75 @@
+// Code generated by gocqltable-gen. DO NOT EDIT.
+
+package rows
+
+import (
+	"github.com/kristoiv/gocqltable"
+	cqlreflect "github.com/kristoiv/gocqltable/reflect"
+)
+
+func init() {
+	gocqltable.RegisterRowMapper(User{}, userRowMapper{})
+}
+
+// userRowMapper maps User rows without reflection.
+type userRowMapper struct{}
+
+func (userRowMapper) Fields() []cqlreflect.FieldInfo {
+	return []cqlreflect.FieldInfo{
+		{Key: "id", Index: []int{0}, Type: "timeuuid"},
+		{Key: "Email", Index: []int{1}},
+		{Key: "visits", Index: []int{3}, ReadOnly: true},
+		{Key: "Nickname", Index: []int{4}, OmitEmpty: true},
+		{Key: "CreatedAt", Index: []int{5, 0}},
+		{Key: "updated_at", Index: []int{5, 1}},
+		{Key: "Lat", Index: []int{6, 0}},
+		{Key: "Long", Index: []int{6, 1}},
+	}
+}
+
+func (userRowMapper) New() interface{} {
+	return new(User)
+}
+
+func (userRowMapper) Values(row interface{}) []interface{} {
+	v, ok := row.(*User)
+	if !ok {
+		value := row.(User)
+		v = &value
+	}
+	values := make([]interface{}, 8)
+	values[0] = v.ID
+	values[1] = v.Email
+	values[2] = v.Visits
+	values[3] = v.Nickname
+	values[4] = v.Audit.CreatedAt
+	values[5] = v.Audit.UpdatedAt
+	if v.Position != nil {
+		values[6] = v.Position.Lat
+	} else {
+		values[6] = *new(float64)
+	}
+	if v.Position != nil {
+		values[7] = v.Position.Long
+	} else {
+		values[7] = *new(float64)
+	}
+	return values
+}
+
+func (userRowMapper) Pointers(row interface{}) []interface{} {
+	v := row.(*User)
+	if v.Position == nil {
+		v.Position = new(Position)
+	}
+	return []interface{}{
+		&v.ID,
+		&v.Email,
+		&v.Visits,
+		&v.Nickname,
+		&v.Audit.CreatedAt,
+		&v.Audit.UpdatedAt,
+		&v.Position.Lat,
+		&v.Position.Long,
+	}
+}
@@ -0,0 +1,756 @@
+package gocqltable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/gocql/gocql"
+	r "github.com/kristoiv/gocqltable/reflect"
+	"gopkg.in/inf.v0"
+)
+
+// boundValue wraps a value bound to a query so it is marshalled by marshal,
+// which knows the types registered with RegisterType, also inside collections,
+// and the naming strategies of user defined types.
+type boundValue struct {
+	value interface{}
+}
+
+func (b boundValue) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	return marshal(info, b.value)
+}
+
+func bindValues(values []interface{}) []interface{} {
+	bound := make([]interface{}, len(values))
+	for i, value := range values {
+		bound[i] = boundValue{value}
+	}
+	return bound
+}
+
+// marshal encodes value for a column described by info.
+func marshal(info gocql.TypeInfo, value interface{}) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	// Nil pointers are null
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+	if rt, ok := lookupType(rv.Type()); ok && rt.marshal != nil {
+		return rt.marshalValue(info, value)
+	}
+	if m, ok := value.(gocql.Marshaler); ok {
+		return m.MarshalCQL(info)
+	}
+	if rv.Kind() == reflect.Ptr {
+		return marshal(info, rv.Elem().Interface())
+	}
+	info = resolveType(info)
+	// gocql wraps a uint8 above 127 into a negative tinyint and a uint16 into a
+	// negative smallint, marshals the zero time.Time as an empty date, rounds
+	// times before 1970 to the next day and has no range check for time
+	switch info.Type() {
+	case gocql.TypeTinyInt:
+		n, err := intValue(value, math.MinInt8, math.MaxInt8)
+		if err != nil {
+			return nil, err
+		}
+		return []byte{byte(n)}, nil
+	case gocql.TypeSmallInt:
+		n, err := intValue(value, math.MinInt16, math.MaxInt16)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, 2)
+		binary.BigEndian.PutUint16(data, uint16(n))
+		return data, nil
+	case gocql.TypeDate:
+		t, ok := value.(time.Time)
+		if !ok {
+			break
+		}
+		days := t.Unix() / 86400
+		if t.Unix()%86400 < 0 {
+			days--
+		}
+		data := make([]byte, 4)
+		binary.BigEndian.PutUint32(data, uint32(days+1<<31))
+		return data, nil
+	case gocql.TypeTime:
+		d, ok := value.(time.Duration)
+		if !ok {
+			break
+		}
+		if d < 0 || d >= 24*time.Hour {
+			return nil, fmt.Errorf("can not marshal %v into time: out of range", d)
+		}
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(d))
+		return data, nil
+	case gocql.TypeDuration:
+		d, ok := value.(time.Duration)
+		if !ok {
+			break
+		}
+		data := appendVint(nil, 0) // months
+		data = appendVint(data, 0) // days
+		return appendVint(data, int64(d)), nil
+	case gocql.TypeList, gocql.TypeSet:
+		return marshalList(info.(typeInfo), value)
+	case gocql.TypeMap:
+		return marshalMap(info.(typeInfo), value)
+	case gocql.TypeUDT:
+		return marshalUDT(info.(typeInfo), value)
+	case gocql.TypeTuple:
+		return marshalTuple(info.(typeInfo), value)
+	case gocql.TypeCustom:
+		if b, ok := value.([]byte); ok {
+			return b, nil
+		}
+	default:
+		return gocql.Marshal(info, value)
+	}
+	return nil, fmt.Errorf("can not marshal %T into %s", value, typeName(info))
+}
+
+// unmarshal decodes data from a column described by info into the value
+// pointed to by value.
+func unmarshal(info gocql.TypeInfo, data []byte, value interface{}) error {
+	dst := reflect.ValueOf(value)
+	if dst.Kind() == reflect.Ptr && !dst.IsNil() && data != nil {
+		if rt, ok := lookupType(dst.Type().Elem()); ok && rt.unmarshal != nil {
+			return rt.unmarshalValue(info, data, dst.Elem())
+		}
+	}
+	if u, ok := value.(gocql.Unmarshaler); ok {
+		return u.UnmarshalCQL(info, data)
+	}
+	if dst.Kind() != reflect.Ptr || dst.IsNil() {
+		return fmt.Errorf("can not unmarshal into non-pointer %T", value)
+	}
+	if data == nil {
+		dst.Elem().Set(reflect.Zero(dst.Elem().Type()))
+		return nil
+	}
+	// Pointers are allocated for values that are not null
+	if dst.Elem().Kind() == reflect.Ptr {
+		v := reflect.New(dst.Elem().Type().Elem())
+		if err := unmarshal(info, data, v.Interface()); err != nil {
+			return err
+		}
+		dst.Elem().Set(v)
+		return nil
+	}
+	info = resolveType(info)
+	// gocql decodes malformed smallint and tinyint data as 0, and decodes
+	// durations only into gocql.Duration
+	switch info.Type() {
+	case gocql.TypeTinyInt:
+		if len(data) != 1 {
+			return fmt.Errorf("can not unmarshal tinyint: expected 1 byte, got %d", len(data))
+		}
+		return setInt(dst.Elem(), int64(int8(data[0])))
+	case gocql.TypeSmallInt:
+		if len(data) != 2 {
+			return fmt.Errorf("can not unmarshal smallint: expected 2 bytes, got %d", len(data))
+		}
+		return setInt(dst.Elem(), int64(int16(binary.BigEndian.Uint16(data))))
+	case gocql.TypeDate:
+		v, ok := value.(*time.Time)
+		if !ok {
+			break
+		}
+		if len(data) != 4 {
+			return fmt.Errorf("can not unmarshal date: expected 4 bytes, got %d", len(data))
+		}
+		days := int64(binary.BigEndian.Uint32(data)) - 1<<31
+		*v = time.Unix(days*86400, 0).UTC()
+		return nil
+	case gocql.TypeTime:
+		v, ok := value.(*time.Duration)
+		if !ok {
+			break
+		}
+		if len(data) != 8 {
+			return fmt.Errorf("can not unmarshal time: expected 8 bytes, got %d", len(data))
+		}
+		*v = time.Duration(binary.BigEndian.Uint64(data))
+		return nil
+	case gocql.TypeDuration:
+		v, ok := value.(*time.Duration)
+		if !ok {
+			break
+		}
+		var months, days, nanos int64
+		var err error
+		if months, data, err = readVint(data); err != nil {
+			return err
+		}
+		if days, data, err = readVint(data); err != nil {
+			return err
+		}
+		if nanos, data, err = readVint(data); err != nil {
+			return err
+		}
+		if months != 0 {
+			return fmt.Errorf("can not unmarshal duration of %d months into %T", months, value)
+		}
+		*v = time.Duration(days)*24*time.Hour + time.Duration(nanos)
+		return nil
+	case gocql.TypeList, gocql.TypeSet:
+		return unmarshalList(info.(typeInfo), data, dst.Elem())
+	case gocql.TypeMap:
+		return unmarshalMap(info.(typeInfo), data, dst.Elem())
+	case gocql.TypeUDT:
+		return unmarshalUDT(info.(typeInfo), data, dst.Elem())
+	case gocql.TypeTuple:
+		return unmarshalTuple(info.(typeInfo), data, dst.Elem())
+	case gocql.TypeCustom:
+		if v, ok := value.(*[]byte); ok {
+			*v = append([]byte(nil), data...)
+			return nil
+		}
+	default:
+		return gocql.Unmarshal(info, data, value)
+	}
+	return fmt.Errorf("can not unmarshal %s into %T", typeName(info), value)
+}
+
+// newValue returns a pointer to the Go type a column described by info is
+// decoded into when there is no row field to decode it into.
+func newValue(info gocql.TypeInfo) interface{} {
+	return reflect.New(goType(info)).Interface()
+}
+
+var (
+	bytesType     = reflect.TypeOf([]byte(nil))
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// goTypes are the Go types the scalar CQL types are decoded into by default.
+var goTypes = map[gocql.Type]reflect.Type{
+	gocql.TypeAscii:     reflect.TypeOf(""),
+	gocql.TypeBigInt:    reflect.TypeOf(int64(0)),
+	gocql.TypeBlob:      bytesType,
+	gocql.TypeBoolean:   reflect.TypeOf(false),
+	gocql.TypeCounter:   reflect.TypeOf(int64(0)),
+	gocql.TypeDecimal:   reflect.TypeOf((*inf.Dec)(nil)),
+	gocql.TypeDouble:    reflect.TypeOf(float64(0)),
+	gocql.TypeFloat:     reflect.TypeOf(float32(0)),
+	gocql.TypeInt:       reflect.TypeOf(0),
+	gocql.TypeTimestamp: reflect.TypeOf(time.Time{}),
+	gocql.TypeUUID:      reflect.TypeOf(gocql.UUID{}),
+	gocql.TypeVarchar:   reflect.TypeOf(""),
+	gocql.TypeVarint:    reflect.TypeOf((*big.Int)(nil)),
+	gocql.TypeTimeUUID:  reflect.TypeOf(gocql.UUID{}),
+	gocql.TypeInet:      reflect.TypeOf(net.IP(nil)),
+	gocql.TypeText:      reflect.TypeOf(""),
+	gocql.TypeTinyInt:   reflect.TypeOf(int8(0)),
+	gocql.TypeSmallInt:  reflect.TypeOf(int16(0)),
+	gocql.TypeDate:      reflect.TypeOf(Date{}),
+	gocql.TypeTime:      reflect.TypeOf(TimeOfDay(0)),
+	gocql.TypeDuration:  reflect.TypeOf(time.Duration(0)),
+}
+
+func goType(info gocql.TypeInfo) reflect.Type {
+	info = resolveType(info)
+	switch info.Type() {
+	case gocql.TypeList, gocql.TypeSet:
+		return reflect.SliceOf(goType(info.(typeInfo).elem))
+	case gocql.TypeMap:
+		key := goType(info.(typeInfo).key)
+		if !key.Comparable() {
+			key = interfaceType
+		}
+		return reflect.MapOf(key, goType(info.(typeInfo).elem))
+	case gocql.TypeUDT:
+		return reflect.TypeOf(map[string]interface{}(nil))
+	case gocql.TypeTuple:
+		return reflect.TypeOf([]interface{}(nil))
+	}
+	if t, ok := goTypes[info.Type()]; ok {
+		return t
+	}
+	return bytesType
+}
+
+// isSet reports whether t is a map[T]struct{}, the Go representation of a
+// CQL set.
+func isSet(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0
+}
+
+func marshalList(info typeInfo, value interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(value)
+	var elems []reflect.Value
+	switch {
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		for i := 0; i < rv.Len(); i++ {
+			elems = append(elems, rv.Index(i))
+		}
+	case isSet(rv.Type()):
+		if rv.IsNil() {
+			return nil, nil
+		}
+		elems = rv.MapKeys()
+	default:
+		return nil, fmt.Errorf("can not marshal %T into %s", value, typeName(info))
+	}
+	data := appendCollectionSize(nil, info, len(elems))
+	for _, elem := range elems {
+		var err error
+		if data, err = appendCollectionElem(data, info, info.elem, elem.Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func unmarshalList(info typeInfo, data []byte, dst reflect.Value) error {
+	n, data, err := readCollectionCount(info, data, 1)
+	if err != nil {
+		return err
+	}
+	switch {
+	case dst.Kind() == reflect.Slice:
+		dst.Set(reflect.MakeSlice(dst.Type(), n, n))
+	case dst.Kind() == reflect.Array:
+		if dst.Len() != n {
+			return fmt.Errorf("can not unmarshal %s of %d elements into %s", typeName(info), n, dst.Type())
+		}
+	case isSet(dst.Type()):
+		dst.Set(reflect.MakeMap(dst.Type()))
+	default:
+		return fmt.Errorf("can not unmarshal %s into %s", typeName(info), dst.Type())
+	}
+	for i := 0; i < n; i++ {
+		var elem []byte
+		if elem, data, err = readCollectionElem(info, data); err != nil {
+			return err
+		}
+		if dst.Kind() != reflect.Map {
+			if err := unmarshal(info.elem, elem, dst.Index(i).Addr().Interface()); err != nil {
+				return err
+			}
+			continue
+		}
+		key := reflect.New(dst.Type().Key())
+		if err := unmarshal(info.elem, elem, key.Interface()); err != nil {
+			return err
+		}
+		dst.SetMapIndex(key.Elem(), reflect.Zero(dst.Type().Elem()))
+	}
+	return nil
+}
+
+func marshalMap(info typeInfo, value interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map {
+		return nil, fmt.Errorf("can not marshal %T into %s", value, typeName(info))
+	}
+	if rv.IsNil() {
+		return nil, nil
+	}
+	data := appendCollectionSize(nil, info, rv.Len())
+	for _, key := range rv.MapKeys() {
+		var err error
+		if data, err = appendCollectionElem(data, info, info.key, key.Interface()); err != nil {
+			return nil, err
+		}
+		if data, err = appendCollectionElem(data, info, info.elem, rv.MapIndex(key).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func unmarshalMap(info typeInfo, data []byte, dst reflect.Value) error {
+	if dst.Kind() != reflect.Map {
+		return fmt.Errorf("can not unmarshal %s into %s", typeName(info), dst.Type())
+	}
+	n, data, err := readCollectionCount(info, data, 2)
+	if err != nil {
+		return err
+	}
+	dst.Set(reflect.MakeMapWithSize(dst.Type(), n))
+	for i := 0; i < n; i++ {
+		var keyData, elemData []byte
+		if keyData, data, err = readCollectionElem(info, data); err != nil {
+			return err
+		}
+		if elemData, data, err = readCollectionElem(info, data); err != nil {
+			return err
+		}
+		key := reflect.New(dst.Type().Key())
+		if err := unmarshal(info.key, keyData, key.Interface()); err != nil {
+			return err
+		}
+		elem := reflect.New(dst.Type().Elem())
+		if err := unmarshal(info.elem, elemData, elem.Interface()); err != nil {
+			return err
+		}
+		dst.SetMapIndex(key.Elem(), elem.Elem())
+	}
+	return nil
+}
+
+// Collection sizes are shorts before protocol version 3 and ints after.
+func collectionSizeLen(info gocql.TypeInfo) int {
+	if info.Version() < 3 {
+		return 2
+	}
+	return 4
+}
+
+func appendCollectionSize(data []byte, info gocql.TypeInfo, n int) []byte {
+	if collectionSizeLen(info) == 2 {
+		return binary.BigEndian.AppendUint16(data, uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(data, uint32(n))
+}
+
+func readCollectionSize(info gocql.TypeInfo, data []byte) (int, []byte, error) {
+	size := collectionSizeLen(info)
+	if len(data) < size {
+		return 0, nil, fmt.Errorf("can not unmarshal %s: unexpected end of data", typeName(info))
+	}
+	if size == 2 {
+		return int(binary.BigEndian.Uint16(data)), data[2:], nil
+	}
+	return int(int32(binary.BigEndian.Uint32(data))), data[4:], nil
+}
+
+// readCollectionCount reads the number of elements of a collection, each of
+// which is perElem sizes and their data. Counts that are negative, or more
+// than the rest of the data can hold, are rejected before anything is
+// allocated for them.
+func readCollectionCount(info gocql.TypeInfo, data []byte, perElem int) (int, []byte, error) {
+	n, data, err := readCollectionSize(info, data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if n < 0 || n > len(data)/(perElem*collectionSizeLen(info)) {
+		return 0, nil, fmt.Errorf("can not unmarshal %s: invalid size %d for %d bytes of data", typeName(info), n, len(data))
+	}
+	return n, data, nil
+}
+
+func appendCollectionElem(data []byte, info, elemInfo gocql.TypeInfo, value interface{}) ([]byte, error) {
+	elem, err := marshal(elemInfo, value)
+	if err != nil {
+		return nil, err
+	}
+	if elem == nil {
+		return nil, fmt.Errorf("can not marshal a null element into %s", typeName(info))
+	}
+	return append(appendCollectionSize(data, info, len(elem)), elem...), nil
+}
+
+func readCollectionElem(info gocql.TypeInfo, data []byte) ([]byte, []byte, error) {
+	n, data, err := readCollectionSize(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n < 0 || len(data) < n {
+		return nil, nil, fmt.Errorf("can not unmarshal %s: unexpected end of data", typeName(info))
+	}
+	return data[:n], data[n:], nil
+}
+
+func typeName(info gocql.TypeInfo) string {
+	if name, err := cassaTypeToString(columnType(info)); err == nil {
+		return name
+	}
+	return fmt.Sprint(info)
+}
+
+func intValue(value interface{}, min, max int64) (int64, error) {
+	v := reflect.ValueOf(value)
+	var n int64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("can not marshal %T: value %d out of range", value, v.Uint())
+		}
+		n = int64(v.Uint())
+	default:
+		return 0, fmt.Errorf("can not marshal %T into an integer column", value)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("can not marshal %T: value %d out of range", value, n)
+	}
+	return n, nil
+}
+
+func setInt(v reflect.Value, n int64) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.OverflowInt(n) {
+			return fmt.Errorf("can not unmarshal %d into %s: out of range", n, v.Type())
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			return fmt.Errorf("can not unmarshal %d into %s: out of range", n, v.Type())
+		}
+		v.SetUint(uint64(n))
+		return nil
+	}
+	return fmt.Errorf("can not unmarshal an integer into %s", v.Type())
+}
+
+// appendVint appends n as a zigzag encoded variable length integer, the
+// encoding Cassandra uses for the parts of a duration.
+func appendVint(data []byte, n int64) []byte {
+	u := uint64(n<<1) ^ uint64(n>>63)
+	size := (639 - bits.LeadingZeros64(u|1)*9) >> 6
+	if size == 9 {
+		data = append(data, 0xFF)
+		return binary.BigEndian.AppendUint64(data, u)
+	}
+	buf := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		buf[i] = byte(u)
+		u >>= 8
+	}
+	buf[0] |= ^byte(0xFF >> uint(size-1))
+	return append(data, buf...)
+}
+
+func readVint(data []byte) (int64, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, fmt.Errorf("can not read vint: no data")
+	}
+	extra := bits.LeadingZeros8(^data[0])
+	if len(data) < extra+1 {
+		return 0, nil, fmt.Errorf("can not read vint: expected %d bytes, got %d", extra+1, len(data))
+	}
+	u := uint64(data[0] & (0xFF >> uint(extra)))
+	for _, b := range data[1 : extra+1] {
+		u = u<<8 | uint64(b)
+	}
+	return int64(u>>1) ^ -int64(u&1), data[extra+1:], nil
+}
+
+// marshalUDT encodes a struct, or a map keyed by field name, into a user defined
+// type. Struct fields are matched to the type's fields by the naming strategy
+// the type was created with, see udtNaming.
+func marshalUDT(info typeInfo, value interface{}) ([]byte, error) {
+	values, err := udtValues(info.keyspace, value)
+	if err != nil {
+		return nil, fmt.Errorf("can not marshal %T into %s: %v", value, typeName(info), err)
+	}
+	var data []byte
+	for i, field := range info.fields {
+		v, ok := values[field]
+		if !ok {
+			data = appendBytes(data, nil)
+			continue
+		}
+		fieldData, err := marshal(info.elems[i], v)
+		if err != nil {
+			return nil, err
+		}
+		data = appendBytes(data, fieldData)
+	}
+	return data, nil
+}
+
+func udtValues(keyspace string, value interface{}) (map[string]interface{}, error) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		values := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			values[key.String()] = rv.MapIndex(key).Interface()
+		}
+		return values, nil
+	case rv.Kind() == reflect.Struct:
+		m, ok := r.StructToMap(rv.Interface())
+		if !ok {
+			return nil, r.Validate(rv.Interface())
+		}
+		naming := udtNaming(keyspace, rv.Type())
+		values := make(map[string]interface{}, len(m))
+		for key, v := range m {
+			values[naming.ColumnName(key)] = v
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("expected a struct or a map")
+}
+
+func unmarshalUDT(info typeInfo, data []byte, dst reflect.Value) error {
+	var fields map[string]reflect.Value
+	switch {
+	case dst.Kind() == reflect.Struct:
+		infos, ok := r.Fields(reflect.Zero(dst.Type()).Interface())
+		if !ok {
+			return r.Validate(reflect.Zero(dst.Type()).Interface())
+		}
+		naming := udtNaming(info.keyspace, dst.Type())
+		fields = make(map[string]reflect.Value, len(infos))
+		for _, field := range infos {
+			if v, ok := r.FieldByIndex(dst, field.Index, true); ok && v.CanSet() {
+				fields[naming.ColumnName(field.Key)] = v
+			}
+		}
+	case dst.Kind() == reflect.Map && dst.Type().Key().Kind() == reflect.String:
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), len(info.fields)))
+	case dst.Kind() == reflect.Interface && dst.NumMethod() == 0:
+		m := make(map[string]interface{}, len(info.fields))
+		if err := unmarshalUDT(info, data, reflect.ValueOf(m)); err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(m))
+		return nil
+	default:
+		return fmt.Errorf("can not unmarshal %s into %s", typeName(info), dst.Type())
+	}
+
+	// Fields added to a type after a value was written are missing from the
+	// end of the value.
+	for i := 0; i < len(info.fields) && len(data) > 0; i++ {
+		var fieldData []byte
+		var err error
+		if fieldData, data, err = readBytes(info, data); err != nil {
+			return err
+		}
+
+		if dst.Kind() == reflect.Map {
+			v := reflect.New(dst.Type().Elem())
+			if dst.Type().Elem() == interfaceType {
+				v = reflect.New(goType(info.elems[i]))
+			}
+			if err := unmarshal(info.elems[i], fieldData, v.Interface()); err != nil {
+				return err
+			}
+			dst.SetMapIndex(reflect.ValueOf(info.fields[i]).Convert(dst.Type().Key()), v.Elem())
+			continue
+		}
+		field, ok := fields[info.fields[i]]
+		if !ok {
+			continue
+		}
+		if err := unmarshal(info.elems[i], fieldData, field.Addr().Interface()); err != nil {
+			return fmt.Errorf("can not unmarshal field %q of %s: %v", info.fields[i], typeName(info), err)
+		}
+	}
+	return nil
+}
+
+// marshalTuple encodes an array, a slice or the exported fields of a struct
+// into a tuple.
+func marshalTuple(info typeInfo, value interface{}) ([]byte, error) {
+	elems, err := tupleElems(reflect.Indirect(reflect.ValueOf(value)))
+	if err != nil {
+		return nil, fmt.Errorf("can not marshal %T into %s: %v", value, typeName(info), err)
+	}
+	if len(elems) != len(info.elems) {
+		return nil, fmt.Errorf("can not marshal %T into %s: expected %d elements, got %d", value, typeName(info), len(info.elems), len(elems))
+	}
+	var data []byte
+	for i, elem := range elems {
+		elemData, err := marshal(info.elems[i], elem.Interface())
+		if err != nil {
+			return nil, err
+		}
+		data = appendBytes(data, elemData)
+	}
+	return data, nil
+}
+
+func unmarshalTuple(info typeInfo, data []byte, dst reflect.Value) error {
+	if dst.Kind() == reflect.Slice {
+		dst.Set(reflect.MakeSlice(dst.Type(), len(info.elems), len(info.elems)))
+	}
+	elems, err := tupleElems(dst)
+	if err != nil {
+		return fmt.Errorf("can not unmarshal %s into %s: %v", typeName(info), dst.Type(), err)
+	}
+	if len(elems) != len(info.elems) {
+		return fmt.Errorf("can not unmarshal %s into %s: expected %d elements, got %d", typeName(info), dst.Type(), len(info.elems), len(elems))
+	}
+	for i, elem := range elems {
+		var elemData []byte
+		if elemData, data, err = readBytes(info, data); err != nil {
+			return err
+		}
+		v := reflect.New(elem.Type())
+		if elem.Kind() == reflect.Interface {
+			v = reflect.New(goType(info.elems[i]))
+		}
+		if err := unmarshal(info.elems[i], elemData, v.Interface()); err != nil {
+			return err
+		}
+		elem.Set(v.Elem())
+	}
+	return nil
+}
+
+func tupleElems(v reflect.Value) ([]reflect.Value, error) {
+	var elems []reflect.Value
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, v.Index(i))
+		}
+	case reflect.Struct:
+		infos, ok := r.Fields(reflect.Zero(v.Type()).Interface())
+		if !ok {
+			return nil, r.Validate(reflect.Zero(v.Type()).Interface())
+		}
+		for _, info := range infos {
+			if v.Type().FieldByIndex(info.Index).PkgPath != "" {
+				continue
+			}
+			elem, ok := r.FieldByIndex(v, info.Index, v.CanSet())
+			if !ok {
+				elem = reflect.Zero(v.Type().FieldByIndex(info.Index).Type)
+			}
+			elems = append(elems, elem)
+		}
+	default:
+		return nil, fmt.Errorf("expected an array, a slice or a struct")
+	}
+	return elems, nil
+}
+
+// appendBytes appends the [bytes] encoding, a length followed by the data, of
+// the elements of tuples and the fields of user defined types.
+func appendBytes(data, value []byte) []byte {
+	if value == nil {
+		return binary.BigEndian.AppendUint32(data, math.MaxUint32) // null
+	}
+	data = binary.BigEndian.AppendUint32(data, uint32(len(value)))
+	return append(data, value...)
+}
+
+func readBytes(info gocql.TypeInfo, data []byte) ([]byte, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf("can not unmarshal %s: unexpected end of data", typeName(info))
+	}
+	n := int32(binary.BigEndian.Uint32(data))
+	data = data[4:]
+	if n < 0 {
+		return nil, data, nil
+	}
+	if len(data) < int(n) {
+		return nil, nil, fmt.Errorf("can not unmarshal %s: unexpected end of data", typeName(info))
+	}
+	return data[:n], data[n:], nil
+}
@@ -0,0 +1,1019 @@
+package gocqltable
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// This file parses the subset of CQL that keyspaces, tables, queries and the
+// recipes generate, for the MemorySession.
+
+type cqlTokenKind int
+
+const (
+	tokenEOF cqlTokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenUUID
+	tokenBlob
+	tokenBind
+	tokenPunct
+)
+
+type cqlToken struct {
+	kind cqlTokenKind
+	text string
+}
+
+func (t cqlToken) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of statement"
+	case tokenQuotedIdent:
+		return fmt.Sprintf("%q", t.text)
+	case tokenString:
+		return stringLiteral(t.text)
+	}
+	return t.text
+}
+
+// lexCQL splits a statement into tokens. Unquoted identifiers and keywords are
+// lower cased, as CQL is case insensitive but for quoted identifiers.
+func lexCQL(s string) ([]cqlToken, error) {
+	var tokens []cqlToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(s[i:], "--") || strings.HasPrefix(s[i:], "//"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated comment in %q", s)
+			}
+			i += end + 4
+		case c == '"' || c == '\'':
+			text, n, err := lexQuoted(s[i:], c)
+			if err != nil {
+				return nil, err
+			}
+			kind := tokenQuotedIdent
+			if c == '\'' {
+				kind = tokenString
+			}
+			tokens = append(tokens, cqlToken{kind, text})
+			i += n
+		case c == '?':
+			tokens = append(tokens, cqlToken{tokenBind, "?"})
+			i++
+		case isUUIDAt(s[i:]):
+			tokens = append(tokens, cqlToken{tokenUUID, s[i : i+36]})
+			i += 36
+		case (c == '0' && i+1 < len(s) && (s[i+1] == 'x' || s[i+1] == 'X')):
+			n := 2
+			for i+n < len(s) && isHexDigit(s[i+n]) {
+				n++
+			}
+			tokens = append(tokens, cqlToken{tokenBlob, strings.ToLower(s[i : i+n])})
+			i += n
+		case isDigit(c) || c == '-' && i+1 < len(s) && isDigit(s[i+1]):
+			n := 1
+			for i+n < len(s) && (isDigit(s[i+n]) || s[i+n] == '.' || s[i+n] == 'e' || s[i+n] == 'E' ||
+				(s[i+n] == '-' || s[i+n] == '+') && (s[i+n-1] == 'e' || s[i+n-1] == 'E')) {
+				n++
+			}
+			tokens = append(tokens, cqlToken{tokenNumber, s[i : i+n]})
+			i += n
+		case c == '_' || unicode.IsLetter(rune(c)):
+			n := 1
+			for i+n < len(s) && (s[i+n] == '_' || isDigit(s[i+n]) || unicode.IsLetter(rune(s[i+n]))) {
+				n++
+			}
+			tokens = append(tokens, cqlToken{tokenIdent, strings.ToLower(s[i : i+n])})
+			i += n
+		default:
+			n := 1
+			if i+1 < len(s) && (c == '<' || c == '>' || c == '!') && s[i+1] == '=' {
+				n = 2
+			} else if !strings.ContainsRune("(),.;=<>*{}[]:+-", rune(c)) {
+				return nil, fmt.Errorf("Unexpected character %q in %q", c, s)
+			}
+			tokens = append(tokens, cqlToken{tokenPunct, s[i : i+n]})
+			i += n
+		}
+	}
+	return append(tokens, cqlToken{kind: tokenEOF}), nil
+}
+
+// lexQuoted returns the text of a quoted identifier or string, in which the
+// quote is escaped by doubling it, and the length of it with the quotes.
+func lexQuoted(s string, quote byte) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != quote {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			b.WriteByte(quote)
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("Unterminated quote in %q", s)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func isUUIDAt(s string) bool {
+	if len(s) < 36 || len(s) > 36 && (s[36] == '_' || isHexDigit(s[36])) {
+		return false
+	}
+	for i := 0; i < 36; i++ {
+		if i == 8 || i == 13 || i == 18 || i == 23 {
+			if s[i] != '-' {
+				return false
+			}
+		} else if !isHexDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// cqlTerm is a value in a statement: a bind marker or a literal.
+type cqlTerm struct {
+	bind    int // index of the bound value, -1 for literals
+	kind    cqlTokenKind
+	literal string
+
+	// elems of collection and tuple literals, with keys for maps
+	collection string // "{", "[" or "("
+	keys       []cqlTerm
+	elems      []cqlTerm
+}
+
+func (t cqlTerm) isNull() bool {
+	return t.bind < 0 && t.kind == tokenIdent && t.literal == "null"
+}
+
+// cqlType is a CQL type such as int, frozen<address> or map<text, int>.
+type cqlType struct {
+	name   string // keyspace qualified for user defined types
+	params []cqlType
+	frozen bool
+}
+
+func (t cqlType) String() string {
+	s := t.name
+	if len(t.params) > 0 {
+		params := make([]string, len(t.params))
+		for i, p := range t.params {
+			params[i] = p.String()
+		}
+		s += "<" + strings.Join(params, ", ") + ">"
+	}
+	if t.frozen {
+		s = "frozen<" + s + ">"
+	}
+	return s
+}
+
+type cqlColumnDef struct {
+	name string
+	typ  cqlType
+}
+
+type cqlRelation struct {
+	column string
+	op     string // =, <, <=, >, >= or in
+	terms  []cqlTerm
+}
+
+type cqlOrdering struct {
+	column string
+	desc   bool
+}
+
+type createKeyspaceStmt struct {
+	name        string
+	ifNotExists bool
+	options     map[string]cqlTerm
+}
+
+type alterKeyspaceStmt struct {
+	name    string
+	options map[string]cqlTerm
+}
+
+type createTypeStmt struct {
+	keyspace, name string
+	ifNotExists    bool
+	fields         []cqlColumnDef
+}
+
+type createTableStmt struct {
+	keyspace, name  string
+	ifNotExists     bool
+	columns         []cqlColumnDef
+	partitionKey    []string
+	clusteringKey   []string
+	clusteringOrder map[string]bool // descending clustering columns
+	options         map[string]cqlTerm
+}
+
+type dropStmt struct {
+	what           string // keyspace, table or type
+	keyspace, name string
+	ifExists       bool
+}
+
+type useStmt struct {
+	keyspace string
+}
+
+type insertStmt struct {
+	keyspace, table string
+	columns         []string
+	values          []cqlTerm
+	ifNotExists     bool
+	ttl             *cqlTerm
+}
+
+type updateStmt struct {
+	keyspace, table string
+	ttl             *cqlTerm
+	columns         []string
+	values          []cqlTerm
+	where           []cqlRelation
+}
+
+type deleteStmt struct {
+	keyspace, table string
+	columns         []string
+	where           []cqlRelation
+}
+
+type selectStmt struct {
+	keyspace, table string
+	columns         []string // nil for *
+	count           bool
+	where           []cqlRelation
+	orderBy         []cqlOrdering
+	limit           *cqlTerm
+	allowFiltering  bool
+}
+
+type cqlParser struct {
+	tokens []cqlToken
+	pos    int
+	binds  int
+}
+
+// parseCQL parses a statement into one of the *Stmt types, and returns it with
+// the number of its bind markers.
+func parseCQL(statement string) (interface{}, int, error) {
+	tokens, err := lexCQL(statement)
+	if err != nil {
+		return nil, 0, err
+	}
+	p := &cqlParser{tokens: tokens}
+	stmt, err := p.statement()
+	if err != nil {
+		return nil, 0, fmt.Errorf("Unable to parse %q: %v", statement, err)
+	}
+	p.punct(";")
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, 0, fmt.Errorf("Unable to parse %q: unexpected %v", statement, t)
+	}
+	return stmt, p.binds, nil
+}
+
+func (p *cqlParser) statement() (interface{}, error) {
+	switch {
+	case p.keyword("create"):
+		switch {
+		case p.keyword("keyspace"):
+			return p.createKeyspace()
+		case p.keyword("type"):
+			return p.createType()
+		case p.keyword("table", "columnfamily"):
+			return p.createTable()
+		}
+	case p.keyword("alter"):
+		if p.keyword("keyspace") {
+			return p.alterKeyspace()
+		}
+	case p.keyword("drop"):
+		return p.drop()
+	case p.keyword("use"):
+		name, err := p.name()
+		return useStmt{name}, err
+	case p.keyword("insert"):
+		return p.insert()
+	case p.keyword("update"):
+		return p.update()
+	case p.keyword("delete"):
+		return p.delete()
+	case p.keyword("select"):
+		return p.selectStatement()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *cqlParser) peek() cqlToken {
+	if p.pos >= len(p.tokens) {
+		return cqlToken{kind: tokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *cqlParser) next() cqlToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *cqlParser) unexpected() error {
+	return fmt.Errorf("unexpected %v", p.peek())
+}
+
+// keyword consumes the next token if it is one of the keywords.
+func (p *cqlParser) keyword(keywords ...string) bool {
+	t := p.peek()
+	if t.kind != tokenIdent {
+		return false
+	}
+	for _, kw := range keywords {
+		if t.text == kw {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *cqlParser) expectKeyword(keywords ...string) error {
+	if !p.keyword(keywords...) {
+		return fmt.Errorf("expected %s but got %v", strings.ToUpper(keywords[0]), p.peek())
+	}
+	return nil
+}
+
+// punct consumes the next token if it is the punctuation s.
+func (p *cqlParser) punct(s string) bool {
+	if t := p.peek(); t.kind == tokenPunct && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *cqlParser) expectPunct(s string) error {
+	if !p.punct(s) {
+		return fmt.Errorf("expected '%s' but got %v", s, p.peek())
+	}
+	return nil
+}
+
+func (p *cqlParser) ifNotExists() (bool, error) {
+	if !p.keyword("if") {
+		return false, nil
+	}
+	if err := p.expectKeyword("not"); err != nil {
+		return false, err
+	}
+	return true, p.expectKeyword("exists")
+}
+
+func (p *cqlParser) name() (string, error) {
+	t := p.peek()
+	if t.kind != tokenIdent && t.kind != tokenQuotedIdent {
+		return "", fmt.Errorf("expected a name but got %v", t)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// qualifiedName parses a name that may be qualified by a keyspace.
+func (p *cqlParser) qualifiedName() (keyspace, name string, err error) {
+	if name, err = p.name(); err != nil {
+		return "", "", err
+	}
+	if p.punct(".") {
+		keyspace = name
+		name, err = p.name()
+	}
+	return keyspace, name, err
+}
+
+func (p *cqlParser) names() ([]string, error) {
+	var names []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.punct(",") {
+			return names, nil
+		}
+	}
+}
+
+func (p *cqlParser) term() (cqlTerm, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenBind:
+		p.binds++
+		return cqlTerm{bind: p.binds - 1}, nil
+	case tokenString, tokenNumber, tokenUUID, tokenBlob:
+		return cqlTerm{bind: -1, kind: t.kind, literal: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false", "null", "nan", "infinity":
+			return cqlTerm{bind: -1, kind: t.kind, literal: t.text}, nil
+		}
+	case tokenPunct:
+		switch t.text {
+		case "{", "[", "(":
+			return p.collection(t.text)
+		}
+	}
+	p.pos--
+	return cqlTerm{}, p.unexpected()
+}
+
+// collection parses the rest of a map, set, list or tuple literal.
+func (p *cqlParser) collection(open string) (cqlTerm, error) {
+	closing := map[string]string{"{": "}", "[": "]", "(": ")"}[open]
+	c := cqlTerm{bind: -1, collection: open}
+	for !p.punct(closing) {
+		if len(c.elems) > 0 {
+			if err := p.expectPunct(","); err != nil {
+				return c, err
+			}
+		}
+		elem, err := p.term()
+		if err != nil {
+			return c, err
+		}
+		if open == "{" && p.punct(":") {
+			c.keys = append(c.keys, elem)
+			if elem, err = p.term(); err != nil {
+				return c, err
+			}
+		}
+		c.elems = append(c.elems, elem)
+	}
+	if len(c.keys) > 0 && len(c.keys) != len(c.elems) {
+		return c, fmt.Errorf("invalid map literal")
+	}
+	return c, nil
+}
+
+func (p *cqlParser) cqlType() (cqlType, error) {
+	keyspace, name, err := p.qualifiedName()
+	if err != nil {
+		return cqlType{}, err
+	}
+	if keyspace != "" {
+		return cqlType{name: keyspace + "." + name}, nil
+	}
+	t := cqlType{name: name}
+	if !p.punct("<") {
+		return t, nil
+	}
+	for {
+		param, err := p.cqlType()
+		if err != nil {
+			return t, err
+		}
+		t.params = append(t.params, param)
+		if p.punct(">") {
+			break
+		}
+		if err := p.expectPunct(","); err != nil {
+			return t, err
+		}
+	}
+	if t.name == "frozen" {
+		if len(t.params) != 1 {
+			return t, fmt.Errorf("frozen takes one type")
+		}
+		frozen := t.params[0]
+		frozen.frozen = true
+		return frozen, nil
+	}
+	return t, nil
+}
+
+// options parses the options of keyspaces and tables: name = value pairs
+// separated by AND.
+func (p *cqlParser) options(options map[string]cqlTerm) error {
+	for {
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expectPunct("="); err != nil {
+			return err
+		}
+		value, err := p.term()
+		if err != nil {
+			return err
+		}
+		options[strings.ToLower(name)] = value
+		if !p.keyword("and") {
+			return nil
+		}
+	}
+}
+
+func (p *cqlParser) createKeyspace() (interface{}, error) {
+	stmt := createKeyspaceStmt{options: make(map[string]cqlTerm)}
+	var err error
+	if stmt.ifNotExists, err = p.ifNotExists(); err != nil {
+		return nil, err
+	}
+	if stmt.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("with"); err != nil {
+		return nil, err
+	}
+	return stmt, p.options(stmt.options)
+}
+
+func (p *cqlParser) alterKeyspace() (interface{}, error) {
+	stmt := alterKeyspaceStmt{options: make(map[string]cqlTerm)}
+	var err error
+	if stmt.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("with"); err != nil {
+		return nil, err
+	}
+	return stmt, p.options(stmt.options)
+}
+
+func (p *cqlParser) createType() (interface{}, error) {
+	var stmt createTypeStmt
+	var err error
+	if stmt.ifNotExists, err = p.ifNotExists(); err != nil {
+		return nil, err
+	}
+	if stmt.keyspace, stmt.name, err = p.qualifiedName(); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	for {
+		var field cqlColumnDef
+		if field.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if field.typ, err = p.cqlType(); err != nil {
+			return nil, err
+		}
+		stmt.fields = append(stmt.fields, field)
+		if p.punct(")") {
+			return stmt, nil
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *cqlParser) createTable() (interface{}, error) {
+	stmt := createTableStmt{clusteringOrder: make(map[string]bool), options: make(map[string]cqlTerm)}
+	var err error
+	if stmt.ifNotExists, err = p.ifNotExists(); err != nil {
+		return nil, err
+	}
+	if stmt.keyspace, stmt.name, err = p.qualifiedName(); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	for {
+		if p.keyword("primary") {
+			if err := p.primaryKey(&stmt); err != nil {
+				return nil, err
+			}
+		} else {
+			var column cqlColumnDef
+			if column.name, err = p.name(); err != nil {
+				return nil, err
+			}
+			if column.typ, err = p.cqlType(); err != nil {
+				return nil, err
+			}
+			p.keyword("static")
+			if p.keyword("primary") {
+				if err := p.expectKeyword("key"); err != nil {
+					return nil, err
+				}
+				stmt.partitionKey = []string{column.name}
+			}
+			stmt.columns = append(stmt.columns, column)
+		}
+		if p.punct(")") {
+			break
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+	}
+	if len(stmt.partitionKey) == 0 {
+		return nil, fmt.Errorf("no PRIMARY KEY specified")
+	}
+
+	if !p.keyword("with") {
+		return stmt, nil
+	}
+	for {
+		switch {
+		case p.keyword("clustering"):
+			if err := p.clusteringOrder(&stmt); err != nil {
+				return nil, err
+			}
+		case p.keyword("compact"):
+			if err := p.expectKeyword("storage"); err != nil {
+				return nil, err
+			}
+		default:
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct("="); err != nil {
+				return nil, err
+			}
+			if stmt.options[name], err = p.term(); err != nil {
+				return nil, err
+			}
+		}
+		if !p.keyword("and") {
+			return stmt, nil
+		}
+	}
+}
+
+// primaryKey parses the rest of a PRIMARY KEY ((a, b), c) definition.
+func (p *cqlParser) primaryKey(stmt *createTableStmt) error {
+	if err := p.expectKeyword("key"); err != nil {
+		return err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+	var err error
+	if p.punct("(") {
+		if stmt.partitionKey, err = p.names(); err != nil {
+			return err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return err
+		}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		stmt.partitionKey = []string{name}
+	}
+	if p.punct(",") {
+		if stmt.clusteringKey, err = p.names(); err != nil {
+			return err
+		}
+	}
+	return p.expectPunct(")")
+}
+
+// clusteringOrder parses the rest of a CLUSTERING ORDER BY (c DESC) option.
+func (p *cqlParser) clusteringOrder(stmt *createTableStmt) error {
+	if err := p.expectKeyword("order"); err != nil {
+		return err
+	}
+	if err := p.expectKeyword("by"); err != nil {
+		return err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+	orderings, err := p.orderings()
+	if err != nil {
+		return err
+	}
+	for _, o := range orderings {
+		stmt.clusteringOrder[o.column] = o.desc
+	}
+	return p.expectPunct(")")
+}
+
+func (p *cqlParser) orderings() ([]cqlOrdering, error) {
+	var orderings []cqlOrdering
+	for {
+		var o cqlOrdering
+		var err error
+		if o.column, err = p.name(); err != nil {
+			return nil, err
+		}
+		if p.keyword("desc") {
+			o.desc = true
+		} else {
+			p.keyword("asc")
+		}
+		orderings = append(orderings, o)
+		if !p.punct(",") {
+			return orderings, nil
+		}
+	}
+}
+
+func (p *cqlParser) drop() (interface{}, error) {
+	var stmt dropStmt
+	switch {
+	case p.keyword("keyspace"):
+		stmt.what = "keyspace"
+	case p.keyword("table", "columnfamily"):
+		stmt.what = "table"
+	case p.keyword("type"):
+		stmt.what = "type"
+	default:
+		return nil, p.unexpected()
+	}
+	if p.keyword("if") {
+		if err := p.expectKeyword("exists"); err != nil {
+			return nil, err
+		}
+		stmt.ifExists = true
+	}
+	var err error
+	if stmt.what == "keyspace" {
+		stmt.name, err = p.name()
+	} else {
+		stmt.keyspace, stmt.name, err = p.qualifiedName()
+	}
+	return stmt, err
+}
+
+// using parses USING TTL and TIMESTAMP options, of which the TTL is kept.
+func (p *cqlParser) using() (*cqlTerm, error) {
+	if !p.keyword("using") {
+		return nil, nil
+	}
+	var ttl *cqlTerm
+	for {
+		isTTL := p.keyword("ttl")
+		if !isTTL {
+			if err := p.expectKeyword("timestamp"); err != nil {
+				return nil, err
+			}
+		}
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if isTTL {
+			ttl = &term
+		}
+		if !p.keyword("and") {
+			return ttl, nil
+		}
+	}
+}
+
+func (p *cqlParser) insert() (interface{}, error) {
+	var stmt insertStmt
+	if err := p.expectKeyword("into"); err != nil {
+		return nil, err
+	}
+	var err error
+	if stmt.keyspace, stmt.table, err = p.qualifiedName(); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	if stmt.columns, err = p.names(); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("values"); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	for len(stmt.values) == 0 || p.punct(",") {
+		value, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		stmt.values = append(stmt.values, value)
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	if len(stmt.values) != len(stmt.columns) {
+		return nil, fmt.Errorf("unmatched column names/values")
+	}
+	if stmt.ifNotExists, err = p.ifNotExists(); err != nil {
+		return nil, err
+	}
+	stmt.ttl, err = p.using()
+	return stmt, err
+}
+
+func (p *cqlParser) update() (interface{}, error) {
+	var stmt updateStmt
+	var err error
+	if stmt.keyspace, stmt.table, err = p.qualifiedName(); err != nil {
+		return nil, err
+	}
+	if stmt.ttl, err = p.using(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("set"); err != nil {
+		return nil, err
+	}
+	for len(stmt.columns) == 0 || p.punct(",") {
+		column, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct("="); err != nil {
+			return nil, err
+		}
+		value, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		stmt.columns = append(stmt.columns, column)
+		stmt.values = append(stmt.values, value)
+	}
+	if err := p.expectKeyword("where"); err != nil {
+		return nil, err
+	}
+	stmt.where, err = p.relations()
+	return stmt, err
+}
+
+func (p *cqlParser) delete() (interface{}, error) {
+	var stmt deleteStmt
+	var err error
+	if !p.keyword("from") {
+		if stmt.columns, err = p.names(); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("from"); err != nil {
+			return nil, err
+		}
+	}
+	if stmt.keyspace, stmt.table, err = p.qualifiedName(); err != nil {
+		return nil, err
+	}
+	if _, err := p.using(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("where"); err != nil {
+		return nil, err
+	}
+	stmt.where, err = p.relations()
+	return stmt, err
+}
+
+func (p *cqlParser) selectStatement() (interface{}, error) {
+	var stmt selectStmt
+	var err error
+	switch {
+	case p.punct("*"):
+	case p.keyword("count"):
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		if !p.punct("*") {
+			if t := p.next(); t.kind != tokenNumber || t.text != "1" {
+				p.pos--
+				return nil, p.unexpected()
+			}
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		stmt.count = true
+	default:
+		if stmt.columns, err = p.names(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("from"); err != nil {
+		return nil, err
+	}
+	if stmt.keyspace, stmt.table, err = p.qualifiedName(); err != nil {
+		return nil, err
+	}
+	if p.keyword("where") {
+		if stmt.where, err = p.relations(); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("order") {
+		if err := p.expectKeyword("by"); err != nil {
+			return nil, err
+		}
+		if stmt.orderBy, err = p.orderings(); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("limit") {
+		limit, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		stmt.limit = &limit
+	}
+	if p.keyword("allow") {
+		if err := p.expectKeyword("filtering"); err != nil {
+			return nil, err
+		}
+		stmt.allowFiltering = true
+	}
+	return stmt, nil
+}
+
+// relations parses the relations of a WHERE clause, joined by AND.
+func (p *cqlParser) relations() ([]cqlRelation, error) {
+	var relations []cqlRelation
+	for {
+		var rel cqlRelation
+		var err error
+		if rel.column, err = p.name(); err != nil {
+			return nil, err
+		}
+		if p.keyword("in") {
+			rel.op = "in"
+			if p.peek().kind == tokenBind {
+				return nil, fmt.Errorf("IN with a single bind marker is not supported")
+			}
+			if err := p.expectPunct("("); err != nil {
+				return nil, err
+			}
+			for !p.punct(")") {
+				if len(rel.terms) > 0 {
+					if err := p.expectPunct(","); err != nil {
+						return nil, err
+					}
+				}
+				term, err := p.term()
+				if err != nil {
+					return nil, err
+				}
+				rel.terms = append(rel.terms, term)
+			}
+		} else {
+			t := p.next()
+			switch t.text {
+			case "=", "<", "<=", ">", ">=":
+				if t.kind != tokenPunct {
+					p.pos--
+					return nil, p.unexpected()
+				}
+				rel.op = t.text
+			default:
+				p.pos--
+				return nil, p.unexpected()
+			}
+			term, err := p.term()
+			if err != nil {
+				return nil, err
+			}
+			rel.terms = []cqlTerm{term}
+		}
+		relations = append(relations, rel)
+		if !p.keyword("and") {
+			return relations, nil
+		}
+	}
+}
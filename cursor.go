@@ -0,0 +1,70 @@
+package gocqltable
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInvalidCursor is returned when decoding a cursor that was not encoded by
+// the Cursor, or with another key.
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+// Cursor encodes page states as opaque strings that clients can send back to
+// resume a listing, see Iterator.PageState. If Key is set the cursors are
+// signed with HMAC-SHA256, so page states that clients made up or changed are
+// rejected.
+//
+// Context binds signed cursors to what they page through, such as the query
+// and the user it runs for: a cursor is only decoded by a Cursor with the same
+// key and context, so a client can not resume one listing with the cursor of
+// another.
+type Cursor struct {
+	Key     []byte
+	Context string
+}
+
+// Encode returns the cursor of a page state. The cursor of a nil state, the
+// state after the last page, is empty.
+func (c Cursor) Encode(state []byte) string {
+	if len(state) == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(append(c.sign(state), state...))
+}
+
+// Decode returns the page state of a cursor. The state of an empty cursor is
+// nil, the state of the first page.
+func (c Cursor) Decode(cursor string) ([]byte, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	n := len(c.sign(nil))
+	if len(data) <= n {
+		return nil, ErrInvalidCursor
+	}
+	mac, state := data[:n], data[n:]
+	if !hmac.Equal(mac, c.sign(state)) {
+		return nil, ErrInvalidCursor
+	}
+	return state, nil
+}
+
+// sign returns the signature of the context and state, which is empty if
+// there is no key.
+func (c Cursor) sign(state []byte) []byte {
+	if len(c.Key) == 0 {
+		return []byte{}
+	}
+	mac := hmac.New(sha256.New, c.Key)
+	binary.Write(mac, binary.BigEndian, uint64(len(c.Context)))
+	mac.Write([]byte(c.Context))
+	mac.Write(state)
+	return mac.Sum(nil)
+}
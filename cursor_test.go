@@ -0,0 +1,48 @@
+package gocqltable
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	state := []byte{0x00, 0x10, 0xfe, 0xff}
+	for _, c := range []Cursor{{}, {Key: []byte("secret")}} {
+		cursor := c.Encode(state)
+		decoded, err := c.Decode(cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, state) {
+			t.Errorf("expected state %x but got %x", state, decoded)
+		}
+
+		if cursor := c.Encode(nil); cursor != "" {
+			t.Errorf("expected the cursor of a nil state to be empty but got %q", cursor)
+		}
+		if decoded, err := c.Decode(""); decoded != nil || err != nil {
+			t.Errorf("expected the state of an empty cursor to be nil but got %x, %v", decoded, err)
+		}
+		if _, err := c.Decode("not a cursor!"); err != ErrInvalidCursor {
+			t.Errorf("expected %v but got %v", ErrInvalidCursor, err)
+		}
+	}
+
+	signed := Cursor{Key: []byte("secret")}
+	forged := Cursor{}.Encode(append(make([]byte, 32), state...))
+	if _, err := signed.Decode(forged); err != ErrInvalidCursor {
+		t.Errorf("expected a forged cursor to fail with %v but got %v", ErrInvalidCursor, err)
+	}
+	other := Cursor{Key: []byte("other")}.Encode(state)
+	if _, err := signed.Decode(other); err != ErrInvalidCursor {
+		t.Errorf("expected a cursor of another key to fail with %v but got %v", ErrInvalidCursor, err)
+	}
+
+	bound := Cursor{Key: []byte("secret"), Context: "logs of ann"}
+	if _, err := bound.Decode(signed.Encode(state)); err != ErrInvalidCursor {
+		t.Errorf("expected a cursor of another context to fail with %v but got %v", ErrInvalidCursor, err)
+	}
+	if decoded, err := bound.Decode(bound.Encode(state)); err != nil || !bytes.Equal(decoded, state) {
+		t.Errorf("expected state %x but got %x, %v", state, decoded, err)
+	}
+}
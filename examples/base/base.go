@@ -90,13 +90,13 @@ func main() {
 	iter := userTable.Query("SELECT * FROM gocqltable_test.users").Fetch()
 	fmt.Println("")
 	fmt.Println("Fetched all from users:")
-	for row := range iter.Range() {
+	for row, err := range iter.All() { // All closes the iterator when the loop ends, and ends with an error if the query fails
+		if err != nil {
+			log.Fatalln(err)
+		}
 		user := row.(*User)        // Our row variable is a pointer to "interface{}", and here we type assert it to a pointer to "User"
 		fmt.Println("User:", user) // Let's just print that
 	}
-	if err := iter.Close(); err != nil {
-		log.Fatalln(err)
-	}
 
 	// You can also fetch a single row, obviously
 	row, err := userTable.Query(`SELECT * FROM gocqltable_test.users WHERE email = ? LIMIT 1`, "2@example.com").FetchRow()
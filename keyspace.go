@@ -1,18 +1,25 @@
 package gocqltable
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/gocql/gocql"
 )
 
 var (
-	defaultSession *gocql.Session
+	defaultSession Session
 )
 
 func SetDefaultSession(s *gocql.Session) {
+	defaultSession = newBackend(s)
+}
+
+// SetDefaultBackend sets the Session new keyspaces run their statements
+// through, like SetDefaultSession does for a gocql session.
+func SetDefaultBackend(s Session) {
 	defaultSession = s
 }
 
@@ -23,7 +30,11 @@ type KeyspaceInterface interface {
 
 type Keyspace struct {
 	name    string
-	session *gocql.Session
+	session Session
+	naming  NamingStrategy
+
+	consistency       *gocql.Consistency
+	serialConsistency *gocql.SerialConsistency
 }
 
 func NewKeyspace(name string) Keyspace {
@@ -33,49 +44,141 @@ func NewKeyspace(name string) Keyspace {
 	}
 }
 
+// KeyspaceOptions are the options of a keyspace that CreateWithOptions and
+// Alter set.
+type KeyspaceOptions struct {
+	// Replication is required by CreateWithOptions, and left as it is by
+	// Alter if nil.
+	Replication Replication
+
+	// DurableWrites is true for keyspaces created with it nil, and left as
+	// it is by Alter if nil.
+	DurableWrites *bool
+
+	// IfNotExists creates the keyspace only if it does not exist yet. Alter
+	// ignores it.
+	IfNotExists bool
+}
+
+// Create creates the keyspace with a replication map of any strategy, see
+// ReplicationMap, and CreateWithOptions for typed strategies.
 func (ks Keyspace) Create(replication map[string]interface{}, durableWrites bool) error {
+	return ks.CreateWithOptions(KeyspaceOptions{
+		Replication:   ReplicationMap(replication),
+		DurableWrites: &durableWrites,
+	})
+}
 
+// CreateWithOptions creates the keyspace with options, such as:
+//
+//	keyspace.CreateWithOptions(gocqltable.KeyspaceOptions{
+//		Replication: gocqltable.NetworkTopologyStrategy{"dc1": 3, "dc2": 2},
+//		IfNotExists: true,
+//	})
+func (ks Keyspace) CreateWithOptions(options KeyspaceOptions) error {
 	if ks.session == nil {
 		ks.session = defaultSession
 	}
-
-	replicationBytes, err := json.Marshal(replication)
+	replication, err := replicationLiteral(options.Replication)
 	if err != nil {
 		return err
 	}
+	stmt := "CREATE KEYSPACE "
+	if options.IfNotExists {
+		stmt += "IF NOT EXISTS "
+	}
+	stmt += fmt.Sprintf(`%q WITH REPLICATION = %s`, ks.Name(), replication)
+	if options.DurableWrites != nil {
+		stmt += fmt.Sprintf(` AND DURABLE_WRITES = %t`, *options.DurableWrites)
+	}
+	return ks.session.Exec(Statement{Stmt: stmt})
+}
 
-	replicationMap := strings.Replace(string(replicationBytes), `"`, `'`, -1)
-
-	durableWritesString := "false"
-	if durableWrites {
-		durableWritesString = "true"
+// Alter changes the replication strategy or durable writes of the keyspace.
+// After changing the replication, repairs are needed to move the data of the
+// keyspace to its new replicas.
+func (ks Keyspace) Alter(options KeyspaceOptions) error {
+	if ks.session == nil {
+		ks.session = defaultSession
 	}
+	var with []string
+	if options.Replication != nil {
+		replication, err := replicationLiteral(options.Replication)
+		if err != nil {
+			return err
+		}
+		with = append(with, "REPLICATION = "+replication)
+	}
+	if options.DurableWrites != nil {
+		with = append(with, fmt.Sprintf("DURABLE_WRITES = %t", *options.DurableWrites))
+	}
+	if len(with) == 0 {
+		return errors.New("No keyspace options to alter")
+	}
+	return ks.session.Exec(Statement{Stmt: fmt.Sprintf(`ALTER KEYSPACE %q WITH %s`, ks.Name(), strings.Join(with, " AND "))})
+}
 
-	return ks.session.Query(fmt.Sprintf(`CREATE KEYSPACE %q WITH REPLICATION = %s AND DURABLE_WRITES = %s`, ks.Name(), replicationMap, durableWritesString)).Exec()
+func (ks Keyspace) Drop() error {
+	if ks.session == nil {
+		ks.session = defaultSession
+	}
+	return ks.session.Exec(Statement{Stmt: fmt.Sprintf(`DROP KEYSPACE %q`, ks.Name())})
+}
 
+// CreateType creates a user defined type from the fields of a struct. Fields of
+// that struct type are then stored as the created type by Table.Create in
+// this keyspace.
+func (ks Keyspace) CreateType(name string, udt interface{}) error {
+	if ks.session == nil {
+		ks.session = defaultSession
+	}
+	fields, err := columnDefinitions(udt, ks.Name(), true, ks.NamingStrategy())
+	if err != nil {
+		return err
+	}
+	err = ks.session.Exec(Statement{Stmt: fmt.Sprintf(`CREATE TYPE %q.%q (%s)`, ks.Name(), name, strings.Join(fields, ", "))})
+	if err != nil {
+		return err
+	}
+	registerUDT(ks.Name(), reflect.Indirect(reflect.ValueOf(udt)).Type(), name, ks.NamingStrategy())
+	return nil
 }
 
-func (ks Keyspace) Drop() error {
+func (ks Keyspace) DropType(name string) error {
 	if ks.session == nil {
 		ks.session = defaultSession
 	}
-	return ks.session.Query(fmt.Sprintf(`DROP KEYSPACE %q`, ks.Name())).Exec()
+	return ks.session.Exec(Statement{Stmt: fmt.Sprintf(`DROP TYPE %q.%q`, ks.Name(), name)})
 }
 
+// Tables returns the names of the tables of the keyspace, see TablesMetadata.
 func (ks Keyspace) Tables() ([]string, error) {
+	tables, err := ks.TablesMetadata()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, table := range tables {
+		names = append(names, table.Name)
+	}
+	return names, nil
+}
+
+// TablesMetadata returns the tables of the keyspace, ordered by name. They are
+// read from the system_schema tables of Cassandra 3.0 and later, or from the
+// system tables that older releases keep the schema in.
+func (ks Keyspace) TablesMetadata() ([]TableMetadata, error) {
 	if ks.session == nil {
 		ks.session = defaultSession
 	}
-	var name string
-	var resultSet []string
-	iterator := ks.session.Query(`SELECT columnfamily_name FROM system.schema_columnfamilies WHERE keyspace_name = ?;`, ks.Name()).Iter()
-	for iterator.Scan(&name) {
-		resultSet = append(resultSet, name)
-	}
-	if err := iterator.Close(); err != nil {
+	version, err := releaseVersion(ks.session)
+	if err != nil {
 		return nil, err
 	}
-	return resultSet, nil
+	if version < 3 {
+		return legacyTablesMetadata(ks.session, ks.Name())
+	}
+	return tablesMetadata(ks.session, ks.Name())
 }
 
 func (ks Keyspace) NewTable(name string, rowKeys, rangeKeys []string, row interface{}) Table {
@@ -90,6 +193,10 @@ func (ks Keyspace) NewTable(name string, rowKeys, rangeKeys []string, row interf
 
 		keyspace: ks,
 		session:  ks.session,
+		naming:   ks.naming,
+
+		consistency:       ks.consistency,
+		serialConsistency: ks.serialConsistency,
 	}
 }
 
@@ -97,13 +204,52 @@ func (ks Keyspace) Name() string {
 	return ks.name
 }
 
+// Session returns the gocql session of the keyspace, which is nil if it runs
+// its statements through another Session, see Backend.
 func (ks Keyspace) Session() *gocql.Session {
+	return gocqlSessionOf(ks.Backend())
+}
+
+func (ks *Keyspace) SetSession(session *gocql.Session) {
+	ks.session = newBackend(session)
+}
+
+// Backend returns the Session the keyspace runs its statements through.
+func (ks Keyspace) Backend() Session {
 	if ks.session == nil {
 		ks.session = defaultSession
 	}
 	return ks.session
 }
 
-func (ks *Keyspace) SetSession(session *gocql.Session) {
+// SetBackend sets the Session the keyspace runs its statements through, such
+// as a MemorySession in tests. Only tables created afterwards use it.
+func (ks *Keyspace) SetBackend(session Session) {
 	ks.session = session
 }
+
+// NamingStrategy returns the naming strategy of the keyspace, which is used by
+// its types and is the default of its tables.
+func (ks Keyspace) NamingStrategy() NamingStrategy {
+	return namingOrDefault(ks.naming)
+}
+
+// SetNamingStrategy sets the naming strategy of the keyspace. Only tables
+// created by NewTable after it is set use it.
+func (ks *Keyspace) SetNamingStrategy(naming NamingStrategy) {
+	ks.naming = naming
+}
+
+// SetConsistency sets the default consistency of the queries of the tables of
+// the keyspace, which is that of the session if it is not set. Only tables
+// created by NewTable after it is set use it.
+func (ks *Keyspace) SetConsistency(c gocql.Consistency) {
+	ks.consistency = &c
+}
+
+// SetSerialConsistency sets the default serial consistency of the queries of
+// the tables of the keyspace. Only tables created by NewTable after it is set
+// use it.
+func (ks *Keyspace) SetSerialConsistency(c gocql.SerialConsistency) {
+	ks.serialConsistency = &c
+}
@@ -0,0 +1,102 @@
+package gocqltable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+// RowMapper maps a row struct type to and from its columns without
+// reflection. Mappers are generated for row structs by the gocqltable-gen
+// command and registered with RegisterRowMapper, after which Table and
+// recipes.CRUD use them in place of the reflect package.
+type RowMapper interface {
+	// Fields returns the FieldInfo of the fields of the struct, as returned
+	// by reflect.Fields.
+	Fields() []r.FieldInfo
+	// New returns a pointer to a new struct.
+	New() interface{}
+	// Values returns the values of the fields of row, a struct or a pointer
+	// to one, in the order of Fields.
+	Values(row interface{}) []interface{}
+	// Pointers returns pointers to the fields of row, a pointer to a struct,
+	// in the order of Fields.
+	Pointers(row interface{}) []interface{}
+}
+
+var rowMappersMutex sync.RWMutex
+var rowMappers = make(map[reflect.Type]RowMapper)
+
+// RegisterRowMapper registers the mapper of the struct type of row.
+func RegisterRowMapper(row interface{}, mapper RowMapper) error {
+	if row == nil || mapper == nil {
+		return errors.New("Unable to register a nil row mapper")
+	}
+	t := reflect.TypeOf(row)
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("Unable to register a row mapper for %T which is not a struct", row)
+	}
+	rowMappersMutex.Lock()
+	rowMappers[t] = mapper
+	rowMappersMutex.Unlock()
+	return nil
+}
+
+func lookupRowMapper(t reflect.Type) (RowMapper, bool) {
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	rowMappersMutex.RLock()
+	mapper, found := rowMappers[t]
+	rowMappersMutex.RUnlock()
+	return mapper, found
+}
+
+// RowToMap converts a row struct to a map like reflect.StructToMap, using the
+// RowMapper of its type if one is registered. An error is returned if row is
+// not a valid row struct, see ValidateRow.
+func RowToMap(row interface{}) (map[string]interface{}, error) {
+	mapper, ok := lookupRowMapper(reflect.TypeOf(row))
+	if !ok {
+		return structToMap(r.StructToMap, row)
+	}
+	fields := mapper.Fields()
+	values := mapper.Values(row)
+	m := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		m[field.Key] = values[i]
+	}
+	return m, nil
+}
+
+// RowToWritableMap converts a row struct to a map like
+// reflect.StructToWritableMap, using the RowMapper of its type if one is
+// registered. An error is returned if row is not a valid row struct, see
+// ValidateRow.
+func RowToWritableMap(row interface{}) (map[string]interface{}, error) {
+	mapper, ok := lookupRowMapper(reflect.TypeOf(row))
+	if !ok {
+		return structToMap(r.StructToWritableMap, row)
+	}
+	fields := mapper.Fields()
+	values := mapper.Values(row)
+	m := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		if field.ReadOnly || field.OmitEmpty && r.IsEmpty(values[i]) {
+			continue
+		}
+		m[field.Key] = values[i]
+	}
+	return m, nil
+}
+
+func structToMap(toMap func(interface{}) (map[string]interface{}, bool), row interface{}) (map[string]interface{}, error) {
+	m, ok := toMap(row)
+	if !ok {
+		return nil, r.Validate(row)
+	}
+	return m, nil
+}
@@ -0,0 +1,88 @@
+package gocqltable
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+type mappedRow struct {
+	ID     int64
+	Name   string `cql:",omitempty"`
+	Visits int32  `cql:"visits,readonly"`
+}
+
+// mappedRowMapper is what gocqltable-gen generates for mappedRow.
+type mappedRowMapper struct{}
+
+func (mappedRowMapper) Fields() []r.FieldInfo {
+	return []r.FieldInfo{
+		{Key: "ID", Index: []int{0}},
+		{Key: "Name", Index: []int{1}, OmitEmpty: true},
+		{Key: "visits", Index: []int{2}, ReadOnly: true},
+	}
+}
+
+func (mappedRowMapper) New() interface{} {
+	return new(mappedRow)
+}
+
+func (mappedRowMapper) Values(row interface{}) []interface{} {
+	v, ok := row.(*mappedRow)
+	if !ok {
+		value := row.(mappedRow)
+		v = &value
+	}
+	return []interface{}{v.ID, v.Name, v.Visits}
+}
+
+func (mappedRowMapper) Pointers(row interface{}) []interface{} {
+	v := row.(*mappedRow)
+	return []interface{}{&v.ID, &v.Name, &v.Visits}
+}
+
+func TestRowMapper(t *testing.T) {
+	if err := RegisterRowMapper(mappedRow{}, mappedRowMapper{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterRowMapper(new(mappedRow), mappedRowMapper{}); err == nil {
+		t.Error("expected an error registering a mapper for a pointer type")
+	}
+
+	row := mappedRow{ID: 1, Visits: 3}
+	m, err := RowToMap(&row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := r.StructToMap(row); !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %v but got %v", expected, m)
+	}
+	m, _ = RowToWritableMap(row)
+	if expected, _ := r.StructToWritableMap(row); !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %v but got %v", expected, m)
+	}
+
+	columns := []gocql.ColumnInfo{
+		{Name: "id", TypeInfo: customType("LongType")},
+		{Name: "name", TypeInfo: customType("UTF8Type")},
+		{Name: "visits", TypeInfo: customType("Int32Type")},
+	}
+	s, err := newRowScanner(reflect.TypeOf(mappedRow{}), columns, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.mapper == nil {
+		t.Fatal("expected the scanner to use the registered mapper")
+	}
+	data := scanData(t)
+	scanned := s.newRow()
+	if err := scanInto(s, data[:3], scanned); err != nil {
+		t.Fatal(err)
+	}
+	if expected := (mappedRow{42, "name", 7}); *scanned.(*mappedRow) != expected {
+		t.Errorf("expected %v but got %v", expected, *scanned.(*mappedRow))
+	}
+}
@@ -0,0 +1,1900 @@
+package gocqltable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+)
+
+// MemorySession is a Session that keeps its keyspaces in memory, for unit
+// tests of code built on tables and the recipes. It understands the CQL they
+// generate: creating and dropping keyspaces, types and tables, and inserting,
+// updating, deleting and selecting rows by their keys, with ORDER BY, LIMIT,
+// ALLOW FILTERING and TTLs. Partitions are kept in token order and rows in
+// clustering order, like Cassandra does.
+type MemorySession struct {
+	mu        sync.Mutex
+	keyspaces map[string]*memoryKeyspace
+	keyspace  string // set by USE
+	now       func() time.Time
+
+	// releaseVersion is the Cassandra release the session reports in
+	// system.local, whose schema tables it emulates.
+	releaseVersion string
+}
+
+// The node that a MemorySession describes in system.local.
+var (
+	memoryDataCenter    = "datacenter1"
+	memoryRack          = "rack1"
+	memoryHostID        = gocql.UUID{0x6a, 0x6d, 0x3e, 0x2f, 0x1b, 0x4c, 0x4d, 0x5e, 0x8f, 0x90, 0xa1, 0xb2, 0xc3, 0xd4, 0xe5, 0xf6}
+	memorySchemaVersion = gocql.UUID{0x59, 0xad, 0x61, 0xd0, 0xc5, 0x40, 0x3b, 0x9a, 0x8f, 0x2c, 0x91, 0x1b, 0x2e, 0x47, 0x3d, 0x86}
+)
+
+// NewMemorySession returns a MemorySession without keyspaces.
+func NewMemorySession() *MemorySession {
+	return &MemorySession{
+		keyspaces:      make(map[string]*memoryKeyspace),
+		now:            time.Now,
+		releaseVersion: "4.1.3",
+	}
+}
+
+func (s *MemorySession) Exec(stmt Statement) error {
+	_, err := s.run(stmt)
+	return err
+}
+
+func (s *MemorySession) Iter(stmt Statement) Iter {
+	iter, err := s.run(stmt)
+	if err != nil {
+		return &memoryIter{err: err}
+	}
+	return iter
+}
+
+// Batch runs the statements of a batch atomically: the partitions of the
+// tables they write are copied and the statements applied to the copies,
+// which are only kept if all of them succeed.
+func (s *MemorySession) Batch(batch Batch) error {
+	if err := batch.validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &memoryExec{session: s, now: s.now()}
+	saved := make(map[*memoryTable]map[string]*memoryPartition)
+	for _, stmt := range batch.Statements {
+		parsed, _, err := parseCQL(stmt.Stmt)
+		if err != nil {
+			return err
+		}
+		var keyspace, name string
+		switch parsed := parsed.(type) {
+		case insertStmt:
+			keyspace, name = parsed.keyspace, parsed.table
+		case updateStmt:
+			keyspace, name = parsed.keyspace, parsed.table
+		case deleteStmt:
+			keyspace, name = parsed.keyspace, parsed.table
+		default:
+			return errors.New("Only INSERT, UPDATE and DELETE statements are allowed in batches")
+		}
+		t, err := e.table(keyspace, name)
+		if err != nil {
+			return err
+		}
+		if _, ok := saved[t]; !ok {
+			saved[t] = t.partitions
+			t.partitions = clonePartitions(t.partitions)
+		}
+	}
+
+	for _, stmt := range batch.Statements {
+		if _, err := s.runLocked(stmt); err != nil {
+			for t, partitions := range saved {
+				t.partitions = partitions
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemorySession) run(stmt Statement) (*memoryIter, error) {
+	if stmt.Context != nil {
+		if err := stmt.Context.Err(); err != nil {
+			return nil, err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runLocked(stmt)
+}
+
+func (s *MemorySession) runLocked(stmt Statement) (*memoryIter, error) {
+	parsed, binds, err := parseCQL(stmt.Stmt)
+	if err != nil {
+		return nil, err
+	}
+	if binds != len(stmt.Values) {
+		return nil, fmt.Errorf("Invalid amount of bind variables: expected %d, got %d", binds, len(stmt.Values))
+	}
+	e := &memoryExec{session: s, values: stmt.Values, now: s.now()}
+	iter := &memoryIter{}
+	switch parsed := parsed.(type) {
+	case createKeyspaceStmt:
+		err = e.createKeyspace(parsed)
+	case alterKeyspaceStmt:
+		err = e.alterKeyspace(parsed)
+	case createTypeStmt:
+		err = e.createType(parsed)
+	case createTableStmt:
+		err = e.createTable(parsed)
+	case dropStmt:
+		err = e.drop(parsed)
+	case useStmt:
+		if _, err = e.keyspace(parsed.keyspace); err == nil {
+			s.keyspace = parsed.keyspace
+		}
+	case insertStmt:
+		err = e.insert(parsed)
+	case updateStmt:
+		err = e.update(parsed)
+	case deleteStmt:
+		err = e.delete(parsed)
+	case selectStmt:
+		iter, err = e.selectRows(parsed, stmt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// Prepare returns the bind markers of a statement and the columns of its
+// rows, which is how Cassandra describes prepared statements to clients. It
+// lets a Server serve the session to gocql sessions.
+func (s *MemorySession) Prepare(stmt string) (binds, columns []gocql.ColumnInfo, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parsed, n, err := parseCQL(stmt)
+	if err != nil {
+		return nil, nil, err
+	}
+	e := &memoryExec{session: s, now: s.now()}
+	b := memoryBinds{binds: make([]gocql.ColumnInfo, n)}
+	switch parsed := parsed.(type) {
+	case insertStmt:
+		if b.table, err = e.table(parsed.keyspace, parsed.table); err != nil {
+			return nil, nil, err
+		}
+		for i, name := range parsed.columns {
+			if column, ok := b.table.byName[name]; ok {
+				b.bind(parsed.values[i], name, column.info)
+			}
+		}
+		b.bindInt(parsed.ttl, "[ttl]")
+	case updateStmt:
+		if b.table, err = e.table(parsed.keyspace, parsed.table); err != nil {
+			return nil, nil, err
+		}
+		b.bindInt(parsed.ttl, "[ttl]")
+		for i, name := range parsed.columns {
+			if column, ok := b.table.byName[name]; ok {
+				b.bind(parsed.values[i], name, column.info)
+			}
+		}
+		b.bindRelations(parsed.where)
+	case deleteStmt:
+		if b.table, err = e.table(parsed.keyspace, parsed.table); err != nil {
+			return nil, nil, err
+		}
+		b.bindRelations(parsed.where)
+	case selectStmt:
+		if b.table, err = e.table(parsed.keyspace, parsed.table); err != nil {
+			return nil, nil, err
+		}
+		b.bindRelations(parsed.where)
+		b.bindInt(parsed.limit, "[limit]")
+		selected, err := b.table.selectColumns(parsed)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns = b.table.columnInfos(parsed, selected)
+	}
+	for i, bind := range b.binds {
+		if bind.TypeInfo == nil {
+			return nil, nil, fmt.Errorf("Unable to prepare %q: the type of bind marker %d is unknown", stmt, i)
+		}
+	}
+	return b.binds, columns, nil
+}
+
+// memoryBinds collects the columns of the bind markers of a statement.
+type memoryBinds struct {
+	table *memoryTable
+	binds []gocql.ColumnInfo
+}
+
+// bind binds the bind markers of a term for a column described by info,
+// including those in collection and tuple literals.
+func (b memoryBinds) bind(term cqlTerm, name string, info gocql.TypeInfo) {
+	if term.bind >= 0 {
+		b.binds[term.bind] = gocql.ColumnInfo{Keyspace: b.table.keyspace, Table: b.table.name, Name: name, TypeInfo: info}
+		return
+	}
+	t, _ := resolveType(info).(typeInfo)
+	for i, elem := range term.elems {
+		switch {
+		case t.typ == gocql.TypeMap && i < len(term.keys):
+			b.bind(term.keys[i], name, t.key)
+			b.bind(elem, name, t.elem)
+		case t.typ == gocql.TypeTuple && i < len(t.elems):
+			b.bind(elem, name, t.elems[i])
+		case t.elem != nil:
+			b.bind(elem, name, t.elem)
+		}
+	}
+}
+
+// bindInt binds an optional int term, such as a TTL or a limit.
+func (b memoryBinds) bindInt(term *cqlTerm, name string) {
+	if term != nil {
+		b.bind(*term, name, typeInfo{typ: gocql.TypeInt, proto: 3})
+	}
+}
+
+func (b memoryBinds) bindRelations(where []cqlRelation) {
+	for _, rel := range where {
+		if column, ok := b.table.byName[rel.column]; ok {
+			for _, term := range rel.terms {
+				b.bind(term, rel.column, column.info)
+			}
+		}
+	}
+}
+
+type memoryKeyspace struct {
+	name          string
+	replication   map[string]string
+	durableWrites bool
+	types         map[string]*memoryType
+	tables        map[string]*memoryTable
+}
+
+type memoryType struct {
+	name   string
+	fields []cqlColumnDef
+	info   typeInfo
+}
+
+type memoryTable struct {
+	keyspace, name string
+	columns        []*memoryColumn // in the order they were defined
+	byName         map[string]*memoryColumn
+	partitionKey   []*memoryColumn
+	clusteringKey  []*memoryColumn
+	options        map[string]string
+	partitions     map[string]*memoryPartition
+}
+
+// Column kinds, named like in the schema tables of Cassandra.
+const (
+	partitionKeyColumn = "partition_key"
+	clusteringColumn   = "clustering"
+	regularColumn      = "regular"
+)
+
+type memoryColumn struct {
+	name     string
+	typ      cqlType
+	info     gocql.TypeInfo
+	kind     string
+	position int // in the partition or clustering key
+	desc     bool
+}
+
+type memoryPartition struct {
+	key   [][]byte
+	token int64
+	rows  []*memoryRow // in clustering order
+}
+
+type memoryRow struct {
+	clustering [][]byte
+	marker     *memoryCell // set by inserts, rows without it live as long as their cells
+	cells      map[string]memoryCell
+}
+
+type memoryCell struct {
+	data    []byte
+	expires time.Time
+}
+
+func clonePartitions(partitions map[string]*memoryPartition) map[string]*memoryPartition {
+	clone := make(map[string]*memoryPartition, len(partitions))
+	for key, p := range partitions {
+		rows := make([]*memoryRow, len(p.rows))
+		for i, row := range p.rows {
+			rows[i] = row.clone()
+		}
+		clone[key] = &memoryPartition{key: p.key, token: p.token, rows: rows}
+	}
+	return clone
+}
+
+func (r *memoryRow) clone() *memoryRow {
+	clone := &memoryRow{clustering: r.clustering, cells: make(map[string]memoryCell, len(r.cells))}
+	if r.marker != nil {
+		marker := *r.marker
+		clone.marker = &marker
+	}
+	for name, cell := range r.cells {
+		clone.cells[name] = cell
+	}
+	return clone
+}
+
+func (c *memoryCell) alive(now time.Time) bool {
+	return c != nil && (c.expires.IsZero() || now.Before(c.expires))
+}
+
+func (row *memoryRow) alive(now time.Time) bool {
+	if row.marker.alive(now) {
+		return true
+	}
+	for _, cell := range row.cells {
+		if cell.alive(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryExec executes a statement with its bound values.
+type memoryExec struct {
+	session *MemorySession
+	values  []interface{}
+	now     time.Time
+}
+
+func (e *memoryExec) keyspace(name string) (*memoryKeyspace, error) {
+	if name == "" {
+		name = e.session.keyspace
+	}
+	if name == "" {
+		return nil, errors.New("No keyspace has been specified. USE a keyspace, or explicitly specify keyspace.tablename")
+	}
+	ks, ok := e.session.keyspaces[name]
+	if !ok {
+		return nil, fmt.Errorf("Keyspace '%s' does not exist", name)
+	}
+	return ks, nil
+}
+
+func (e *memoryExec) table(keyspace, name string) (*memoryTable, error) {
+	if t, ok := e.systemTable(keyspace, name); ok {
+		return t, nil
+	}
+	ks, err := e.keyspace(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := ks.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("unconfigured table %s", name)
+	}
+	return t, nil
+}
+
+func (e *memoryExec) createKeyspace(stmt createKeyspaceStmt) error {
+	if _, ok := e.session.keyspaces[stmt.name]; ok {
+		if stmt.ifNotExists {
+			return nil
+		}
+		return fmt.Errorf("Cannot add existing keyspace %q", stmt.name)
+	}
+	ks := &memoryKeyspace{
+		name:          stmt.name,
+		durableWrites: true,
+		types:         make(map[string]*memoryType),
+		tables:        make(map[string]*memoryTable),
+	}
+	if err := e.keyspaceOptions(ks, stmt.options); err != nil {
+		return err
+	}
+	if ks.replication == nil {
+		return errors.New("Missing mandatory replication strategy class")
+	}
+	e.session.keyspaces[stmt.name] = ks
+	return nil
+}
+
+func (e *memoryExec) alterKeyspace(stmt alterKeyspaceStmt) error {
+	ks, err := e.keyspace(stmt.name)
+	if err != nil {
+		return err
+	}
+	return e.keyspaceOptions(ks, stmt.options)
+}
+
+func (e *memoryExec) keyspaceOptions(ks *memoryKeyspace, options map[string]cqlTerm) error {
+	for name, option := range options {
+		value, err := e.termValue(option)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "replication":
+			m, ok := value.(map[string]string)
+			if !ok || m["class"] == "" {
+				return errors.New("Missing mandatory replication strategy class")
+			}
+			ks.replication = m
+		case "durable_writes":
+			b, ok := value.(bool)
+			if !ok {
+				if b, err = strconv.ParseBool(fmt.Sprint(value)); err != nil {
+					return fmt.Errorf("Invalid value for durable_writes: %v", value)
+				}
+			}
+			ks.durableWrites = b
+		default:
+			return fmt.Errorf("Unknown property '%s'", name)
+		}
+	}
+	return nil
+}
+
+func (e *memoryExec) createType(stmt createTypeStmt) error {
+	ks, err := e.keyspace(stmt.keyspace)
+	if err != nil {
+		return err
+	}
+	if _, ok := ks.types[stmt.name]; ok {
+		if stmt.ifNotExists {
+			return nil
+		}
+		return fmt.Errorf("A user type of name %s.%s already exists", ks.name, stmt.name)
+	}
+	t := &memoryType{
+		name:   stmt.name,
+		fields: stmt.fields,
+		info:   typeInfo{typ: gocql.TypeUDT, proto: 3, keyspace: ks.name, name: stmt.name},
+	}
+	for _, field := range stmt.fields {
+		info, err := ks.typeInfo(field.typ)
+		if err != nil {
+			return err
+		}
+		t.info.fields = append(t.info.fields, field.name)
+		t.info.elems = append(t.info.elems, resolveNested(info))
+	}
+	ks.types[stmt.name] = t
+	return nil
+}
+
+func (e *memoryExec) createTable(stmt createTableStmt) error {
+	ks, err := e.keyspace(stmt.keyspace)
+	if err != nil {
+		return err
+	}
+	if _, ok := ks.tables[stmt.name]; ok {
+		if stmt.ifNotExists {
+			return nil
+		}
+		return fmt.Errorf("Cannot add already existing table %q to keyspace %q", stmt.name, ks.name)
+	}
+	t := &memoryTable{
+		keyspace:   ks.name,
+		name:       stmt.name,
+		byName:     make(map[string]*memoryColumn),
+		options:    make(map[string]string),
+		partitions: make(map[string]*memoryPartition),
+	}
+	for _, def := range stmt.columns {
+		if _, ok := t.byName[def.name]; ok {
+			return fmt.Errorf("Multiple definition of identifier %s", def.name)
+		}
+		info, err := ks.typeInfo(def.typ)
+		if err != nil {
+			return err
+		}
+		column := &memoryColumn{name: def.name, typ: def.typ, info: info, kind: regularColumn}
+		t.columns = append(t.columns, column)
+		t.byName[def.name] = column
+	}
+	for i, name := range stmt.partitionKey {
+		column, ok := t.byName[name]
+		if !ok {
+			return fmt.Errorf("Unknown definition %s referenced in PRIMARY KEY", name)
+		}
+		column.kind, column.position = partitionKeyColumn, i
+		t.partitionKey = append(t.partitionKey, column)
+	}
+	for i, name := range stmt.clusteringKey {
+		column, ok := t.byName[name]
+		if !ok {
+			return fmt.Errorf("Unknown definition %s referenced in PRIMARY KEY", name)
+		}
+		if column.kind != regularColumn {
+			return fmt.Errorf("Column %s appears twice in PRIMARY KEY", name)
+		}
+		column.kind, column.position = clusteringColumn, i
+		t.clusteringKey = append(t.clusteringKey, column)
+	}
+	for name, desc := range stmt.clusteringOrder {
+		column, ok := t.byName[name]
+		if !ok || column.kind != clusteringColumn {
+			return fmt.Errorf("Only clustering key columns can be defined in CLUSTERING ORDER directive: %s", name)
+		}
+		column.desc = desc
+	}
+	for name, option := range stmt.options {
+		value, err := e.termValue(option)
+		if err != nil {
+			return err
+		}
+		t.options[name] = fmt.Sprint(value)
+	}
+	ks.tables[stmt.name] = t
+	return nil
+}
+
+func (e *memoryExec) drop(stmt dropStmt) error {
+	if stmt.what == "keyspace" {
+		if _, ok := e.session.keyspaces[stmt.name]; !ok {
+			if stmt.ifExists {
+				return nil
+			}
+			return fmt.Errorf("Cannot drop non existing keyspace '%s'.", stmt.name)
+		}
+		delete(e.session.keyspaces, stmt.name)
+		if e.session.keyspace == stmt.name {
+			e.session.keyspace = ""
+		}
+		return nil
+	}
+
+	ks, err := e.keyspace(stmt.keyspace)
+	if err != nil {
+		if stmt.ifExists {
+			return nil
+		}
+		return err
+	}
+	if stmt.what == "type" {
+		if _, ok := ks.types[stmt.name]; !ok {
+			if stmt.ifExists {
+				return nil
+			}
+			return fmt.Errorf("No user type named %s exists.", stmt.name)
+		}
+		for _, t := range ks.tables {
+			for _, column := range t.columns {
+				if column.typ.uses(stmt.name) {
+					return fmt.Errorf("Cannot drop user type %s.%s as it is still used by table %s.%s", ks.name, stmt.name, ks.name, t.name)
+				}
+			}
+		}
+		delete(ks.types, stmt.name)
+		return nil
+	}
+	if _, ok := ks.tables[stmt.name]; !ok {
+		if stmt.ifExists {
+			return nil
+		}
+		return fmt.Errorf("Cannot drop non existing table '%s' in keyspace '%s'.", stmt.name, ks.name)
+	}
+	delete(ks.tables, stmt.name)
+	return nil
+}
+
+// uses reports whether t is, or contains, the user defined type name.
+func (t cqlType) uses(name string) bool {
+	if t.name == name {
+		return true
+	}
+	for _, param := range t.params {
+		if param.uses(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// nativeTypes are the CQL types without parameters, by name.
+var nativeTypes = map[string]gocql.Type{
+	"ascii":     gocql.TypeAscii,
+	"bigint":    gocql.TypeBigInt,
+	"blob":      gocql.TypeBlob,
+	"boolean":   gocql.TypeBoolean,
+	"counter":   gocql.TypeCounter,
+	"date":      gocql.TypeDate,
+	"decimal":   gocql.TypeDecimal,
+	"double":    gocql.TypeDouble,
+	"duration":  gocql.TypeDuration,
+	"float":     gocql.TypeFloat,
+	"inet":      gocql.TypeInet,
+	"int":       gocql.TypeInt,
+	"smallint":  gocql.TypeSmallInt,
+	"text":      gocql.TypeVarchar,
+	"time":      gocql.TypeTime,
+	"timestamp": gocql.TypeTimestamp,
+	"timeuuid":  gocql.TypeTimeUUID,
+	"tinyint":   gocql.TypeTinyInt,
+	"uuid":      gocql.TypeUUID,
+	"varchar":   gocql.TypeVarchar,
+	"varint":    gocql.TypeVarint,
+}
+
+// typeInfo returns the description of t for the codec.
+func (ks *memoryKeyspace) typeInfo(t cqlType) (gocql.TypeInfo, error) {
+	if typ, ok := nativeTypes[t.name]; ok && len(t.params) == 0 {
+		return typeInfo{typ: typ, proto: 3}, nil
+	}
+	params := make([]gocql.TypeInfo, len(t.params))
+	for i, param := range t.params {
+		info, err := ks.typeInfo(param)
+		if err != nil {
+			return nil, err
+		}
+		params[i] = resolveNested(info)
+	}
+	switch {
+	case (t.name == "list" || t.name == "set") && len(params) == 1:
+		var typ gocql.Type = gocql.TypeList
+		if t.name == "set" {
+			typ = gocql.TypeSet
+		}
+		return typeInfo{typ: typ, proto: 3, elem: params[0]}, nil
+	case t.name == "map" && len(params) == 2:
+		return typeInfo{typ: gocql.TypeMap, proto: 3, key: params[0], elem: params[1]}, nil
+	case t.name == "tuple" && len(params) > 0:
+		return typeInfo{typ: gocql.TypeTuple, proto: 3, elems: params}, nil
+	case len(params) == 0:
+		name := t.name
+		if i := strings.Index(name, "."); i >= 0 {
+			if name[:i] != ks.name {
+				return nil, fmt.Errorf("Statement on keyspace %s cannot refer to a user type in keyspace %s", ks.name, name[:i])
+			}
+			name = name[i+1:]
+		}
+		if udt, ok := ks.types[name]; ok {
+			return udt.info, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown type %s", t)
+}
+
+// termValue returns the Go value of a term that is not typed by a column,
+// such as an option.
+func (e *memoryExec) termValue(term cqlTerm) (interface{}, error) {
+	if term.bind >= 0 {
+		return e.values[term.bind], nil
+	}
+	if term.collection == "{" && len(term.keys) > 0 {
+		m := make(map[string]string, len(term.keys))
+		for i, key := range term.keys {
+			k, err := e.termValue(key)
+			if err != nil {
+				return nil, err
+			}
+			v, err := e.termValue(term.elems[i])
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(k)] = fmt.Sprint(v)
+		}
+		return m, nil
+	}
+	switch term.literal {
+	case "true", "false":
+		if term.kind == tokenIdent {
+			return term.literal == "true", nil
+		}
+	}
+	return term.literal, nil
+}
+
+// data returns the encoding of a term for a column described by info.
+func (e *memoryExec) data(info gocql.TypeInfo, term cqlTerm) ([]byte, error) {
+	var value interface{}
+	if term.bind >= 0 {
+		value = e.values[term.bind]
+	} else {
+		var err error
+		if value, err = literalValue(info, term); err != nil {
+			return nil, err
+		}
+	}
+	return marshal(info, value)
+}
+
+// literalValue returns the Go value of a literal for a column described by
+// info.
+func literalValue(info gocql.TypeInfo, term cqlTerm) (interface{}, error) {
+	info = resolveType(info)
+	if term.isNull() {
+		return nil, nil
+	}
+	if term.collection != "" {
+		t, _ := info.(typeInfo)
+		var elems []gocql.TypeInfo
+		switch {
+		case t.typ == gocql.TypeMap && term.collection == "{":
+			m := make(map[interface{}]interface{}, len(term.elems))
+			for i, elem := range term.elems {
+				if i >= len(term.keys) {
+					return nil, fmt.Errorf("Invalid set literal for %s", typeName(info))
+				}
+				key, err := literalValue(t.key, term.keys[i])
+				if err != nil {
+					return nil, err
+				}
+				if m[key], err = literalValue(t.elem, elem); err != nil {
+					return nil, err
+				}
+			}
+			return m, nil
+		case (t.typ == gocql.TypeList || t.typ == gocql.TypeSet) && len(term.keys) == 0:
+			for range term.elems {
+				elems = append(elems, t.elem)
+			}
+		case t.typ == gocql.TypeTuple && term.collection == "(" && len(term.elems) == len(t.elems):
+			elems = t.elems
+		default:
+			return nil, fmt.Errorf("Invalid collection literal for %s", typeName(info))
+		}
+		values := make([]interface{}, len(term.elems))
+		for i, elem := range term.elems {
+			var err error
+			if values[i], err = literalValue(elems[i], elem); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	s := term.literal
+	switch term.kind {
+	case tokenIdent:
+		switch s {
+		case "true", "false":
+			return s == "true", nil
+		case "nan":
+			return math.NaN(), nil
+		case "infinity":
+			return math.Inf(1), nil
+		}
+	case tokenUUID:
+		return gocql.ParseUUID(s)
+	case tokenBlob:
+		return hex.DecodeString(s[2:])
+	case tokenNumber:
+		switch info.Type() {
+		case gocql.TypeFloat:
+			f, err := strconv.ParseFloat(s, 32)
+			return float32(f), err
+		case gocql.TypeDouble:
+			return strconv.ParseFloat(s, 64)
+		case gocql.TypeDecimal:
+			d, ok := new(inf.Dec).SetString(s)
+			if !ok {
+				return nil, fmt.Errorf("Invalid decimal literal %s", s)
+			}
+			return d, nil
+		case gocql.TypeVarint:
+			n, ok := new(big.Int).SetString(s, 10)
+			if !ok {
+				return nil, fmt.Errorf("Invalid varint literal %s", s)
+			}
+			return n, nil
+		case gocql.TypeTimestamp:
+			ms, err := strconv.ParseInt(s, 10, 64)
+			return time.Unix(0, ms*int64(time.Millisecond)).UTC(), err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case tokenString:
+		switch info.Type() {
+		case gocql.TypeTimestamp:
+			for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05Z0700", "2006-01-02 15:04:05", "2006-01-02"} {
+				if t, err := time.Parse(layout, s); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("Unable to coerce '%s' to a formatted date", s)
+		case gocql.TypeDate:
+			return time.Parse("2006-01-02", s)
+		case gocql.TypeUUID, gocql.TypeTimeUUID:
+			return gocql.ParseUUID(s)
+		case gocql.TypeInet:
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("Unable to make inet address from '%s'", s)
+			}
+			return ip, nil
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("Invalid literal %s for %s", s, typeName(info))
+}
+
+// intTerm returns the value of a term that is an integer, such as a TTL or a
+// limit.
+func (e *memoryExec) intTerm(term cqlTerm, what string) (int64, error) {
+	var value interface{} = term.literal
+	if term.bind >= 0 {
+		value = e.values[term.bind]
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.String:
+		if n, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid %s value %v", what, value)
+}
+
+func (e *memoryExec) expires(ttl *cqlTerm) (time.Time, error) {
+	if ttl == nil {
+		return time.Time{}, nil
+	}
+	seconds, err := e.intTerm(*ttl, "TTL")
+	if err != nil {
+		return time.Time{}, err
+	}
+	if seconds < 0 {
+		return time.Time{}, fmt.Errorf("A TTL must be greater or equal to 0, but was %d", seconds)
+	}
+	if seconds == 0 {
+		return time.Time{}, nil
+	}
+	return e.now.Add(time.Duration(seconds) * time.Second), nil
+}
+
+func (e *memoryExec) insert(stmt insertStmt) error {
+	t, err := e.table(stmt.keyspace, stmt.table)
+	if err != nil {
+		return err
+	}
+	expires, err := e.expires(stmt.ttl)
+	if err != nil {
+		return err
+	}
+	values := make(map[string][]byte, len(stmt.columns))
+	for i, name := range stmt.columns {
+		column, ok := t.byName[name]
+		if !ok {
+			return fmt.Errorf("Undefined column name %s", name)
+		}
+		if _, ok := values[name]; ok {
+			return fmt.Errorf("Multiple definitions found for column %s", name)
+		}
+		if values[name], err = e.data(column.info, stmt.values[i]); err != nil {
+			return err
+		}
+	}
+	key, clustering, err := t.primaryKey(values)
+	if err != nil {
+		return err
+	}
+
+	if stmt.ifNotExists {
+		if row := t.row(key, clustering, false); row != nil && row.alive(e.now) {
+			return nil
+		}
+	}
+	row := t.row(key, clustering, true)
+	row.marker = &memoryCell{expires: expires}
+	for name, data := range values {
+		if t.byName[name].kind == regularColumn {
+			row.set(name, data, expires)
+		}
+	}
+	return nil
+}
+
+func (row *memoryRow) set(name string, data []byte, expires time.Time) {
+	if data == nil {
+		delete(row.cells, name)
+		return
+	}
+	row.cells[name] = memoryCell{data: data, expires: expires}
+}
+
+// primaryKey returns the partition key and clustering columns of a row from
+// its values by column name.
+func (t *memoryTable) primaryKey(values map[string][]byte) (key, clustering [][]byte, err error) {
+	for _, column := range t.partitionKey {
+		data, ok := values[column.name]
+		if !ok {
+			return nil, nil, fmt.Errorf("Some partition key parts are missing: %s", column.name)
+		}
+		if data == nil {
+			return nil, nil, fmt.Errorf("Invalid null value for partition key part %s", column.name)
+		}
+		key = append(key, data)
+	}
+	for _, column := range t.clusteringKey {
+		data, ok := values[column.name]
+		if !ok {
+			return nil, nil, fmt.Errorf("Some clustering keys are missing: %s", column.name)
+		}
+		if data == nil {
+			return nil, nil, fmt.Errorf("Invalid null value for clustering key part %s", column.name)
+		}
+		clustering = append(clustering, data)
+	}
+	return key, clustering, nil
+}
+
+// row returns the row of a primary key, creating it if create is set.
+func (t *memoryTable) row(key, clustering [][]byte, create bool) *memoryRow {
+	p, ok := t.partitions[partitionID(key)]
+	if !ok {
+		if !create {
+			return nil
+		}
+		p = &memoryPartition{key: key, token: partitionToken(key)}
+		t.partitions[partitionID(key)] = p
+	}
+	i := sort.Search(len(p.rows), func(i int) bool {
+		return t.compareClustering(p.rows[i].clustering, clustering) >= 0
+	})
+	if i < len(p.rows) && t.compareClustering(p.rows[i].clustering, clustering) == 0 {
+		return p.rows[i]
+	}
+	if !create {
+		return nil
+	}
+	row := &memoryRow{clustering: clustering, cells: make(map[string]memoryCell)}
+	p.rows = append(p.rows, nil)
+	copy(p.rows[i+1:], p.rows[i:])
+	p.rows[i] = row
+	return row
+}
+
+// compareClustering compares clustering columns in the clustering order of
+// the table.
+func (t *memoryTable) compareClustering(a, b [][]byte) int {
+	for i, column := range t.clusteringKey {
+		if c := compareData(column.info, a[i], b[i]); c != 0 {
+			if column.desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+func (e *memoryExec) update(stmt updateStmt) error {
+	t, err := e.table(stmt.keyspace, stmt.table)
+	if err != nil {
+		return err
+	}
+	expires, err := e.expires(stmt.ttl)
+	if err != nil {
+		return err
+	}
+	keys, clusterings, err := e.primaryKeys(t, stmt.where, true)
+	if err != nil {
+		return err
+	}
+	values := make(map[string][]byte, len(stmt.columns))
+	for i, name := range stmt.columns {
+		column, ok := t.byName[name]
+		if !ok {
+			return fmt.Errorf("Undefined column name %s", name)
+		}
+		if column.kind != regularColumn {
+			return fmt.Errorf("PRIMARY KEY part %s found in SET part", name)
+		}
+		if values[name], err = e.data(column.info, stmt.values[i]); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		for _, clustering := range clusterings {
+			row := t.row(key, clustering, true)
+			for name, data := range values {
+				row.set(name, data, expires)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *memoryExec) delete(stmt deleteStmt) error {
+	t, err := e.table(stmt.keyspace, stmt.table)
+	if err != nil {
+		return err
+	}
+	for _, name := range stmt.columns {
+		column, ok := t.byName[name]
+		if !ok {
+			return fmt.Errorf("Undefined column name %s", name)
+		}
+		if column.kind != regularColumn {
+			return fmt.Errorf("Invalid identifier %s for deletion (should not be a PRIMARY KEY part)", name)
+		}
+	}
+	keys, clusterings, err := e.primaryKeys(t, stmt.where, false)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		p, ok := t.partitions[partitionID(key)]
+		if !ok {
+			continue
+		}
+		rows := p.rows[:0]
+		for _, row := range p.rows {
+			if !matchesPrefix(t, row.clustering, clusterings) {
+				rows = append(rows, row)
+				continue
+			}
+			if len(stmt.columns) > 0 {
+				for _, name := range stmt.columns {
+					delete(row.cells, name)
+				}
+				rows = append(rows, row)
+			}
+		}
+		p.rows = rows
+		if len(p.rows) == 0 {
+			delete(t.partitions, partitionID(key))
+		}
+	}
+	return nil
+}
+
+// matchesPrefix reports whether clustering starts with one of prefixes.
+func matchesPrefix(t *memoryTable, clustering [][]byte, prefixes [][][]byte) bool {
+	for _, prefix := range prefixes {
+		matches := true
+		for i, data := range prefix {
+			if compareData(t.clusteringKey[i].info, clustering[i], data) != 0 {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryKeys returns the partition keys and clustering columns that the
+// relations of an update or delete select by = and IN. All clustering columns
+// must be selected if full is set, else a prefix of them may be.
+func (e *memoryExec) primaryKeys(t *memoryTable, where []cqlRelation, full bool) (keys, clusterings [][][]byte, err error) {
+	restrictions, err := e.restrictions(t, where)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, rs := range restrictions {
+		if t.byName[name].kind == regularColumn {
+			return nil, nil, fmt.Errorf("Non PRIMARY KEY columns found in where clause: %s", name)
+		}
+		for _, r := range rs {
+			if r.op != "=" && r.op != "in" {
+				return nil, nil, fmt.Errorf("Invalid operator %s for PRIMARY KEY part %s", r.op, name)
+			}
+		}
+	}
+	if keys, err = equalities(t.partitionKey, restrictions, true, "Some partition key parts are missing: %s"); err != nil {
+		return nil, nil, err
+	}
+	if clusterings, err = equalities(t.clusteringKey, restrictions, full, "Some clustering keys are missing: %s"); err != nil {
+		return nil, nil, err
+	}
+	return keys, clusterings, nil
+}
+
+// equalities returns the combinations of the values the columns are
+// restricted to by = and IN, for all the columns or a prefix of them.
+func equalities(columns []*memoryColumn, restrictions map[string][]memoryRestriction, all bool, missing string) ([][][]byte, error) {
+	combinations := [][][]byte{nil}
+	for i, column := range columns {
+		rs := restrictions[column.name]
+		if len(rs) == 0 {
+			if all {
+				return nil, fmt.Errorf(missing, column.name)
+			}
+			for _, later := range columns[i+1:] {
+				if len(restrictions[later.name]) > 0 {
+					return nil, fmt.Errorf("PRIMARY KEY column %q cannot be restricted as preceding column %q is not restricted", later.name, column.name)
+				}
+			}
+			break
+		}
+		var next [][][]byte
+		for _, combination := range combinations {
+			for _, data := range rs[0].values {
+				if data == nil {
+					return nil, fmt.Errorf("Invalid null value in condition for column %s", column.name)
+				}
+				next = append(next, append(append([][]byte{}, combination...), data))
+			}
+		}
+		combinations = next
+	}
+	return combinations, nil
+}
+
+// memoryRestriction restricts a column by a relation of a WHERE clause.
+type memoryRestriction struct {
+	op     string
+	values [][]byte
+}
+
+func (r memoryRestriction) matches(info gocql.TypeInfo, data []byte) bool {
+	if data == nil {
+		return false
+	}
+	if r.op == "in" {
+		for _, value := range r.values {
+			if compareData(info, data, value) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	c := compareData(info, data, r.values[0])
+	switch r.op {
+	case "=":
+		return c == 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func (e *memoryExec) restrictions(t *memoryTable, where []cqlRelation) (map[string][]memoryRestriction, error) {
+	restrictions := make(map[string][]memoryRestriction)
+	for _, rel := range where {
+		column, ok := t.byName[rel.column]
+		if !ok {
+			return nil, fmt.Errorf("Undefined column name %s", rel.column)
+		}
+		r := memoryRestriction{op: rel.op}
+		for _, term := range rel.terms {
+			data, err := e.data(column.info, term)
+			if err != nil {
+				return nil, err
+			}
+			r.values = append(r.values, data)
+		}
+		if (r.op == "=" || r.op == "in") && len(restrictions[rel.column]) > 0 {
+			return nil, fmt.Errorf("%s cannot be restricted by more than one relation if it includes an Equal", rel.column)
+		}
+		restrictions[rel.column] = append(restrictions[rel.column], r)
+	}
+	return restrictions, nil
+}
+
+func (e *memoryExec) selectRows(stmt selectStmt, statement Statement) (*memoryIter, error) {
+	t, err := e.table(stmt.keyspace, stmt.table)
+	if err != nil {
+		return nil, err
+	}
+	restrictions, err := e.restrictions(t, stmt.where)
+	if err != nil {
+		return nil, err
+	}
+
+	// The partitions are looked up by their keys if they are restricted by =
+	// and IN, and scanned in token order otherwise
+	var partitions []*memoryPartition
+	keyed := true
+	for _, column := range t.partitionKey {
+		rs := restrictions[column.name]
+		if len(rs) != 1 || rs[0].op != "=" && rs[0].op != "in" {
+			keyed = false
+		}
+	}
+	if keyed {
+		keys, err := equalities(t.partitionKey, restrictions, true, "Some partition key parts are missing: %s")
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if p, ok := t.partitions[partitionID(key)]; ok {
+				partitions = append(partitions, p)
+			}
+		}
+	} else {
+		for _, p := range t.partitions {
+			partitions = append(partitions, p)
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].token < partitions[j].token
+	})
+
+	filtering := false
+	for name := range restrictions {
+		switch t.byName[name].kind {
+		case partitionKeyColumn:
+			filtering = filtering || !keyed
+		case clusteringColumn:
+			filtering = filtering || !keyed
+		default:
+			filtering = true
+		}
+	}
+	if filtering && !stmt.allowFiltering {
+		return nil, errors.New("Cannot execute this query as it might involve data filtering and thus may have unpredictable performance. If you want to execute this query despite the performance unpredictability, use ALLOW FILTERING")
+	}
+
+	reversed := false
+	for i, o := range stmt.orderBy {
+		column, ok := t.byName[o.column]
+		if !ok {
+			return nil, fmt.Errorf("Undefined column name %s", o.column)
+		}
+		if column.kind != clusteringColumn {
+			return nil, fmt.Errorf("Order by is currently only supported on the clustered columns of the PRIMARY KEY, got %s", o.column)
+		}
+		if column.position != i {
+			return nil, errors.New("Order by currently only supports the ordering of columns following their declared order in the PRIMARY KEY")
+		}
+		if i == 0 {
+			reversed = o.desc != column.desc
+		} else if (o.desc != column.desc) != reversed {
+			return nil, fmt.Errorf("Unsupported order by relation")
+		}
+	}
+	if len(stmt.orderBy) > 0 && !keyed {
+		return nil, errors.New("ORDER BY is only supported when the partition key is restricted by an EQ or an IN.")
+	}
+
+	limit := int64(-1)
+	if stmt.limit != nil {
+		if limit, err = e.intTerm(*stmt.limit, "LIMIT"); err != nil {
+			return nil, err
+		}
+		if limit <= 0 {
+			return nil, fmt.Errorf("LIMIT must be strictly positive")
+		}
+	}
+
+	columns, err := t.selectColumns(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows [][][]byte
+	for _, p := range partitions {
+		prows := p.rows
+		if reversed {
+			prows = make([]*memoryRow, len(p.rows))
+			for i, row := range p.rows {
+				prows[len(p.rows)-1-i] = row
+			}
+		}
+		for _, row := range prows {
+			if limit >= 0 && int64(len(rows)) >= limit {
+				break
+			}
+			if !row.alive(e.now) {
+				continue
+			}
+			values := t.rowValues(p, row, e.now)
+			if !matches(t, restrictions, values) {
+				continue
+			}
+			data := make([][]byte, len(columns))
+			for i, column := range columns {
+				data[i] = values[column.name]
+			}
+			rows = append(rows, data)
+		}
+	}
+
+	iter := &memoryIter{columns: t.columnInfos(stmt, columns), rows: rows}
+	if stmt.count {
+		count, _ := marshal(iter.columns[0].TypeInfo, int64(len(rows)))
+		iter.rows = [][][]byte{{count}}
+		return iter, nil
+	}
+
+	if statement.Paged && statement.PageSize > 0 {
+		offset := 0
+		if len(statement.PageState) == 8 {
+			offset = int(binary.BigEndian.Uint64(statement.PageState))
+		} else if len(statement.PageState) > 0 {
+			return nil, errors.New("Invalid value for the paging state")
+		}
+		if offset > len(rows) {
+			offset = len(rows)
+		}
+		end := offset + statement.PageSize
+		if end < len(rows) {
+			iter.pageState = make([]byte, 8)
+			binary.BigEndian.PutUint64(iter.pageState, uint64(end))
+		} else {
+			end = len(rows)
+		}
+		iter.rows = rows[offset:end]
+	}
+	return iter, nil
+}
+
+// selectColumns returns the columns a select statement selects.
+func (t *memoryTable) selectColumns(stmt selectStmt) ([]*memoryColumn, error) {
+	if stmt.columns == nil {
+		return t.selectAll(), nil
+	}
+	var columns []*memoryColumn
+	for _, name := range stmt.columns {
+		column, ok := t.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("Undefined column name %s", name)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// columnInfos returns the columns of the rows of a select statement.
+func (t *memoryTable) columnInfos(stmt selectStmt, columns []*memoryColumn) []gocql.ColumnInfo {
+	if stmt.count {
+		return []gocql.ColumnInfo{{Keyspace: t.keyspace, Table: t.name, Name: "count", TypeInfo: typeInfo{typ: gocql.TypeBigInt, proto: 3}}}
+	}
+	infos := make([]gocql.ColumnInfo, len(columns))
+	for i, column := range columns {
+		infos[i] = gocql.ColumnInfo{Keyspace: t.keyspace, Table: t.name, Name: column.name, TypeInfo: column.info}
+	}
+	return infos
+}
+
+// selectAll returns the columns of SELECT *: the partition key, the
+// clustering columns and the other columns by name.
+func (t *memoryTable) selectAll() []*memoryColumn {
+	columns := append(append([]*memoryColumn{}, t.partitionKey...), t.clusteringKey...)
+	var regular []*memoryColumn
+	for _, column := range t.columns {
+		if column.kind == regularColumn {
+			regular = append(regular, column)
+		}
+	}
+	sort.Slice(regular, func(i, j int) bool {
+		return regular[i].name < regular[j].name
+	})
+	return append(columns, regular...)
+}
+
+// rowValues returns the live values of a row by column name.
+func (t *memoryTable) rowValues(p *memoryPartition, row *memoryRow, now time.Time) map[string][]byte {
+	values := make(map[string][]byte, len(t.columns))
+	for i, column := range t.partitionKey {
+		values[column.name] = p.key[i]
+	}
+	for i, column := range t.clusteringKey {
+		values[column.name] = row.clustering[i]
+	}
+	for name, cell := range row.cells {
+		if cell.alive(now) {
+			values[name] = cell.data
+		}
+	}
+	return values
+}
+
+func matches(t *memoryTable, restrictions map[string][]memoryRestriction, values map[string][]byte) bool {
+	for name, rs := range restrictions {
+		for _, r := range rs {
+			if !r.matches(t.byName[name].info, values[name]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareData compares two encoded values of a type, nulls first.
+func compareData(info gocql.TypeInfo, a, b []byte) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		}
+		return 1
+	}
+	info = resolveType(info)
+	switch info.Type() {
+	case gocql.TypeBlob, gocql.TypeAscii, gocql.TypeVarchar, gocql.TypeInet, gocql.TypeCustom:
+		return bytes.Compare(a, b)
+	case gocql.TypeTimeUUID:
+		ua, errA := gocql.UUIDFromBytes(a)
+		ub, errB := gocql.UUIDFromBytes(b)
+		if errA == nil && errB == nil {
+			if ta, tb := ua.Time(), ub.Time(); !ta.Equal(tb) {
+				if ta.Before(tb) {
+					return -1
+				}
+				return 1
+			}
+		}
+		return bytes.Compare(a, b)
+	}
+	va, vb := newValue(info), newValue(info)
+	if unmarshal(info, a, va) != nil || unmarshal(info, b, vb) != nil {
+		return bytes.Compare(a, b)
+	}
+	x, y := reflect.ValueOf(va).Elem(), reflect.ValueOf(vb).Elem()
+	switch x := x.Interface().(type) {
+	case time.Time:
+		y := y.Interface().(time.Time)
+		switch {
+		case x.Before(y):
+			return -1
+		case x.After(y):
+			return 1
+		}
+		return 0
+	case *big.Int:
+		return x.Cmp(y.Interface().(*big.Int))
+	case *inf.Dec:
+		return x.Cmp(y.Interface().(*inf.Dec))
+	}
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(x.Int(), y.Int())
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(x.Float(), y.Float())
+	case reflect.Bool:
+		return compareOrdered(boolInt(x.Bool()), boolInt(y.Bool()))
+	}
+	return bytes.Compare(a, b)
+}
+
+func compareOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// partitionID returns the key of a partition in the partitions of a table.
+func partitionID(key [][]byte) string {
+	return string(serializePartitionKey(key))
+}
+
+// serializePartitionKey returns the partition key as it is hashed into a
+// token: the value of a single column, or the values of composite keys each
+// prefixed by its length and followed by a zero byte.
+func serializePartitionKey(key [][]byte) []byte {
+	if len(key) == 1 {
+		return key[0]
+	}
+	var data []byte
+	for _, component := range key {
+		data = append(data, byte(len(component)>>8), byte(len(component)))
+		data = append(data, component...)
+		data = append(data, 0)
+	}
+	return data
+}
+
+// partitionToken returns the token of a partition key by the Murmur3
+// partitioner, the default of Cassandra.
+func partitionToken(key [][]byte) int64 {
+	token := int64(murmur3H1(serializePartitionKey(key)))
+	if token == math.MinInt64 {
+		return math.MaxInt64
+	}
+	return token
+}
+
+// murmur3H1 returns the first half of the 128 bit x64 MurmurHash3 of data as
+// Cassandra computes it, which sign extends the bytes of the tail.
+func murmur3H1(data []byte) uint64 {
+	const (
+		c1 = 0x87c37b91114253d5
+		c2 = 0x4cf5ad432745937f
+	)
+	rotl := func(x uint64, r uint) uint64 {
+		return x<<r | x>>(64-r)
+	}
+	var h1, h2 uint64
+	n := len(data) / 16
+	for i := 0; i < n; i++ {
+		k1 := binary.LittleEndian.Uint64(data[i*16:])
+		k2 := binary.LittleEndian.Uint64(data[i*16+8:])
+
+		k1 *= c1
+		k1 = rotl(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+		h1 = rotl(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2
+		k2 = rotl(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+		h2 = rotl(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	tail := data[n*16:]
+	var k1, k2 uint64
+	for i := len(tail) - 1; i >= 0; i-- {
+		b := uint64(int64(int8(tail[i])))
+		if i >= 8 {
+			k2 ^= b << (8 * uint(i-8))
+		} else {
+			k1 ^= b << (8 * uint(i))
+		}
+	}
+	if len(tail) > 8 {
+		k2 *= c2
+		k2 = rotl(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+	}
+	if len(tail) > 0 {
+		k1 *= c1
+		k1 = rotl(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+	}
+
+	h1 ^= uint64(len(data))
+	h2 ^= uint64(len(data))
+	h1 += h2
+	h2 += h1
+	h1 = fmix64(h1)
+	h2 = fmix64(h2)
+	h1 += h2
+	return h1
+}
+
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
+
+// systemTable returns the system.local table and the schema tables of
+// Cassandra that keyspaces query, filled from the keyspaces of the session.
+// The schema tables are those of Cassandra 3.0 and later, or of 2.x if the
+// session reports an older release.
+func (e *memoryExec) systemTable(keyspace, name string) (*memoryTable, bool) {
+	legacy := strings.HasPrefix(e.session.releaseVersion, "2.")
+	var t *memoryTable
+	switch {
+	case keyspace == "system" && name == "local":
+		// The session is a single node, which drivers connect to on startup
+		t = newSystemTable(keyspace, name, []string{"key"}, nil)
+		t.addSystemRow(map[string]interface{}{
+			"key":               "local",
+			"release_version":   e.session.releaseVersion,
+			"cluster_name":      "Test Cluster",
+			"data_center":       memoryDataCenter,
+			"rack":              memoryRack,
+			"host_id":           memoryHostID,
+			"schema_version":    memorySchemaVersion,
+			"partitioner":       "org.apache.cassandra.dht.Murmur3Partitioner",
+			"tokens":            []string{"0"},
+			"rpc_address":       net.IPv4(127, 0, 0, 1),
+			"broadcast_address": net.IPv4(127, 0, 0, 1),
+			"listen_address":    net.IPv4(127, 0, 0, 1),
+		})
+	case keyspace == "system" && name == "peers":
+		t = newSystemTable(keyspace, name, []string{"peer"}, nil)
+		t.addSystemColumns(map[string]interface{}{
+			"release_version": "",
+			"data_center":     "",
+			"rack":            "",
+			"host_id":         gocql.UUID{},
+			"schema_version":  gocql.UUID{},
+			"tokens":          []string{},
+			"rpc_address":     net.IP{},
+		})
+	case keyspace == "system_schema" && name == "tables" && !legacy:
+		t = newSystemTable(keyspace, name, []string{"keyspace_name"}, []string{"table_name"})
+		t.addSystemColumns(map[string]interface{}{"comment": "", "default_time_to_live": 0})
+		e.eachSystemTable(func(table *memoryTable) {
+			t.addSystemRow(map[string]interface{}{"keyspace_name": table.keyspace, "table_name": table.name, "comment": table.options["comment"], "default_time_to_live": table.defaultTTL()})
+		})
+	case keyspace == "system_schema" && name == "columns" && !legacy:
+		t = newSystemTable(keyspace, name, []string{"keyspace_name"}, []string{"table_name", "column_name"})
+		t.addSystemColumns(map[string]interface{}{"kind": "", "position": 0, "clustering_order": "", "type": ""})
+		e.eachSystemTable(func(table *memoryTable) {
+			for _, column := range table.columns {
+				position, order := -1, "none"
+				if column.kind != regularColumn {
+					position = column.position
+				}
+				if column.kind == clusteringColumn {
+					order = "asc"
+					if column.desc {
+						order = "desc"
+					}
+				}
+				t.addSystemRow(map[string]interface{}{"keyspace_name": table.keyspace, "table_name": table.name, "column_name": column.name, "kind": column.kind, "position": position, "clustering_order": order, "type": column.typ.String()})
+			}
+		})
+	case keyspace == "system" && name == "schema_columnfamilies" && legacy:
+		t = newSystemTable(keyspace, name, []string{"keyspace_name"}, []string{"columnfamily_name"})
+		t.addSystemColumns(map[string]interface{}{"comment": "", "default_time_to_live": 0})
+		e.eachSystemTable(func(table *memoryTable) {
+			t.addSystemRow(map[string]interface{}{"keyspace_name": table.keyspace, "columnfamily_name": table.name, "comment": table.options["comment"], "default_time_to_live": table.defaultTTL()})
+		})
+	case keyspace == "system" && name == "schema_columns" && legacy:
+		t = newSystemTable(keyspace, name, []string{"keyspace_name"}, []string{"columnfamily_name", "column_name"})
+		t.addSystemColumns(map[string]interface{}{"type": "", "component_index": 0, "validator": ""})
+		e.eachSystemTable(func(table *memoryTable) {
+			for _, column := range table.columns {
+				row := map[string]interface{}{"keyspace_name": table.keyspace, "columnfamily_name": table.name, "column_name": column.name, "type": column.kind, "validator": marshalClass(column.info)}
+				if column.kind != regularColumn {
+					row["component_index"] = column.position
+				}
+				if column.kind == clusteringColumn {
+					row["type"] = "clustering_key"
+					if column.desc {
+						row["validator"] = marshalClassPrefix + "ReversedType(" + marshalClass(column.info) + ")"
+					}
+				}
+				t.addSystemRow(row)
+			}
+		})
+	default:
+		return nil, false
+	}
+	return t, true
+}
+
+// eachSystemTable calls f with each table of the keyspaces of the session.
+func (e *memoryExec) eachSystemTable(f func(table *memoryTable)) {
+	for _, ks := range e.session.keyspaces {
+		for _, table := range ks.tables {
+			f(table)
+		}
+	}
+}
+
+// defaultTTL returns the default_time_to_live of a table, in seconds.
+func (t *memoryTable) defaultTTL() int {
+	ttl, _ := strconv.Atoi(t.options["default_time_to_live"])
+	return ttl
+}
+
+// newSystemTable returns a table of text columns.
+func newSystemTable(keyspace, name string, partitionKey, clusteringKey []string) *memoryTable {
+	t := &memoryTable{
+		keyspace:   keyspace,
+		name:       name,
+		byName:     make(map[string]*memoryColumn),
+		partitions: make(map[string]*memoryPartition),
+	}
+	for i, name := range partitionKey {
+		column := &memoryColumn{name: name, typ: cqlType{name: "text"}, info: typeInfo{typ: gocql.TypeVarchar, proto: 3}, kind: partitionKeyColumn, position: i}
+		t.columns = append(t.columns, column)
+		t.partitionKey = append(t.partitionKey, column)
+		t.byName[name] = column
+	}
+	for i, name := range clusteringKey {
+		column := &memoryColumn{name: name, typ: cqlType{name: "text"}, info: typeInfo{typ: gocql.TypeVarchar, proto: 3}, kind: clusteringColumn, position: i}
+		t.columns = append(t.columns, column)
+		t.clusteringKey = append(t.clusteringKey, column)
+		t.byName[name] = column
+	}
+	return t
+}
+
+// addSystemColumns adds regular columns to a system table, of the types of
+// values: int, uuid, inet and set<text> columns for ints, UUIDs, IPs and
+// string slices, and text columns for the others.
+func (t *memoryTable) addSystemColumns(values map[string]interface{}) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := t.byName[name]; ok {
+			continue
+		}
+		value := values[name]
+		column := &memoryColumn{name: name, typ: cqlType{name: "text"}, info: typeInfo{typ: gocql.TypeVarchar, proto: 3}, kind: regularColumn}
+		switch value.(type) {
+		case int:
+			column.typ, column.info = cqlType{name: "int"}, typeInfo{typ: gocql.TypeInt, proto: 3}
+		case gocql.UUID:
+			column.typ, column.info = cqlType{name: "uuid"}, typeInfo{typ: gocql.TypeUUID, proto: 3}
+		case net.IP:
+			column.typ, column.info = cqlType{name: "inet"}, typeInfo{typ: gocql.TypeInet, proto: 3}
+		case []string:
+			column.typ = cqlType{name: "set", params: []cqlType{{name: "text"}}}
+			column.info = typeInfo{typ: gocql.TypeSet, proto: 3, elem: typeInfo{typ: gocql.TypeVarchar, proto: 3}}
+		}
+		t.columns = append(t.columns, column)
+		t.byName[name] = column
+	}
+}
+
+// addSystemRow adds a row to a system table, adding columns for values of
+// columns it does not have yet, see addSystemColumns.
+func (t *memoryTable) addSystemRow(values map[string]interface{}) {
+	t.addSystemColumns(values)
+	data := make(map[string][]byte, len(values))
+	for name, value := range values {
+		data[name], _ = marshal(t.byName[name].info, value)
+	}
+	key, clustering, err := t.primaryKey(data)
+	if err != nil {
+		return
+	}
+	row := t.row(key, clustering, true)
+	row.marker = &memoryCell{}
+	for name, d := range data {
+		if t.byName[name].kind == regularColumn {
+			row.set(name, d, time.Time{})
+		}
+	}
+}
+
+// memoryIter is the Iter of the rows of a MemorySession.
+type memoryIter struct {
+	columns   []gocql.ColumnInfo
+	rows      [][][]byte
+	pos       int
+	pageState []byte
+	err       error
+}
+
+// bufferIter reads the rows of iter into a memoryIter without decoding them,
+// and closes iter.
+func bufferIter(iter Iter) *memoryIter {
+	b := &memoryIter{columns: iter.Columns()}
+	for {
+		var dest []interface{}
+		row := make([]rawColumn, len(b.columns))
+		for i, column := range b.columns {
+			if n, ok := tupleSize(column.TypeInfo); ok {
+				row[i].elems = make([]rawColumn, n)
+				for e := range row[i].elems {
+					dest = append(dest, &row[i].elems[e])
+				}
+				continue
+			}
+			dest = append(dest, &row[i])
+		}
+		if !iter.Scan(dest...) {
+			break
+		}
+		data := make([][]byte, len(row))
+		for i := range row {
+			data[i] = row[i].bytes()
+		}
+		b.rows = append(b.rows, data)
+	}
+	b.pageState = iter.PageState()
+	b.err = iter.Close()
+	return b
+}
+
+func (iter *memoryIter) Columns() []gocql.ColumnInfo {
+	return iter.columns
+}
+
+// Scan scans like gocql.Iter.Scan, which takes a destination per element of
+// tuple columns.
+func (iter *memoryIter) Scan(dest ...interface{}) bool {
+	if iter.err != nil || iter.pos >= len(iter.rows) {
+		return false
+	}
+	count := 0
+	for _, column := range iter.columns {
+		if n, ok := tupleSize(column.TypeInfo); ok {
+			count += n
+		} else {
+			count++
+		}
+	}
+	if len(dest) != count {
+		iter.err = errors.New("count mismatch")
+		return false
+	}
+
+	row := iter.rows[iter.pos]
+	i := 0
+	for c, column := range iter.columns {
+		if dest[i] == nil {
+			i++
+			continue
+		}
+		if n, ok := tupleSize(column.TypeInfo); ok {
+			if iter.err = unmarshalTupleElems(column.TypeInfo.(typeInfo), row[c], dest[i:i+n]); iter.err != nil {
+				return false
+			}
+			i += n
+			continue
+		}
+		if iter.err = unmarshal(column.TypeInfo, row[c], dest[i]); iter.err != nil {
+			return false
+		}
+		i++
+	}
+	iter.pos++
+	return true
+}
+
+// unmarshalTupleElems decodes the elements of a tuple into dest.
+func unmarshalTupleElems(info typeInfo, data []byte, dest []interface{}) error {
+	for i, elem := range info.elems {
+		var p []byte
+		if data != nil {
+			var err error
+			if p, data, err = readBytes(info, data); err != nil {
+				return err
+			}
+		}
+		if dest[i] == nil {
+			continue
+		}
+		if err := unmarshal(elem, p, dest[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (iter *memoryIter) PageState() []byte {
+	return iter.pageState
+}
+
+func (iter *memoryIter) Close() error {
+	return iter.err
+}
@@ -0,0 +1,267 @@
+package gocqltable
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+type memoryEvent struct {
+	Stream  string
+	Seq     int
+	Payload string
+	Tags    []string
+}
+
+func memoryEventsTable(tb testing.TB) (*MemorySession, Table) {
+	s := NewMemorySession()
+	ks := NewKeyspace("events")
+	ks.SetBackend(s)
+	if err := ks.Create(map[string]interface{}{"class": "SimpleStrategy", "replication_factor": 1}, true); err != nil {
+		tb.Fatal(err)
+	}
+	table := ks.NewTable("events", []string{"Stream"}, []string{"Seq"}, memoryEvent{})
+	if err := table.CreateWithProperties(`CLUSTERING ORDER BY ("seq" DESC)`); err != nil {
+		tb.Fatal(err)
+	}
+	for _, stream := range []string{"a", "b", "c"} {
+		for seq := 1; seq <= 3; seq++ {
+			err := table.Query(`INSERT INTO "events"."events" ("stream", "seq", "payload", "tags") VALUES (?, ?, ?, ?)`, stream, seq, stream+"-payload", []string{stream}).Exec()
+			if err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	return s, table
+}
+
+func memoryFetch(tb testing.TB, q Query) []memoryEvent {
+	var events []memoryEvent
+	for row, err := range q.Fetch().All() {
+		if err != nil {
+			tb.Fatal(err)
+		}
+		events = append(events, *row.(*memoryEvent))
+	}
+	return events
+}
+
+func memorySeqs(events []memoryEvent) []int {
+	seqs := []int{}
+	for _, event := range events {
+		seqs = append(seqs, event.Seq)
+	}
+	return seqs
+}
+
+func TestMemorySessionSelect(t *testing.T) {
+	_, table := memoryEventsTable(t)
+
+	event, err := table.Query(`SELECT * FROM "events"."events" WHERE "stream" = ? AND "seq" = ?`, "b", 2).FetchRow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (memoryEvent{"b", 2, "b-payload", []string{"b"}}); !reflect.DeepEqual(*event.(*memoryEvent), expected) {
+		t.Errorf("expected %v but got %v", expected, *event.(*memoryEvent))
+	}
+
+	tests := []struct {
+		stmt   string
+		values []interface{}
+		seqs   []int
+	}{
+		{`SELECT * FROM "events"."events" WHERE "stream" = ?`, []interface{}{"a"}, []int{3, 2, 1}},
+		{`SELECT * FROM "events"."events" WHERE "stream" = ? ORDER BY "seq" ASC`, []interface{}{"a"}, []int{1, 2, 3}},
+		{`SELECT * FROM "events"."events" WHERE "stream" = ? AND "seq" < ? LIMIT 1`, []interface{}{"a", 3}, []int{2}},
+		{`SELECT * FROM "events"."events" WHERE "stream" IN ('a', 'c') AND "seq" >= 3`, nil, []int{3, 3}},
+		{`SELECT * FROM "events"."events" WHERE "payload" = 'c-payload' ALLOW FILTERING`, nil, []int{3, 2, 1}},
+	}
+	for _, test := range tests {
+		if seqs := memorySeqs(memoryFetch(t, table.Query(test.stmt, test.values...))); !reflect.DeepEqual(seqs, test.seqs) {
+			t.Errorf("%s: expected %v but got %v", test.stmt, test.seqs, seqs)
+		}
+	}
+
+	if err := table.Query(`SELECT * FROM "events"."events" WHERE "payload" = 'c-payload'`).Fetch().Close(); err == nil {
+		t.Error("expected filtering without ALLOW FILTERING to fail")
+	}
+	if err := table.Query(`SELECT * FROM "events"."events" ORDER BY "seq" ASC`).Fetch().Close(); err == nil {
+		t.Error("expected ordering without a partition key to fail")
+	}
+
+	// Partitions are scanned in token order, whatever the order of the keys
+	var streams []string
+	for _, event := range memoryFetch(t, table.Query(`SELECT * FROM "events"."events" WHERE "seq" = 1 ALLOW FILTERING`)) {
+		streams = append(streams, event.Stream)
+	}
+	if expected := []string{"a", "c", "b"}; !reflect.DeepEqual(streams, expected) {
+		t.Errorf("expected partitions %v but got %v", expected, streams)
+	}
+}
+
+func TestMemorySessionWrites(t *testing.T) {
+	s, table := memoryEventsTable(t)
+
+	if err := table.Query(`UPDATE "events"."events" SET "payload" = ? WHERE "stream" = ? AND "seq" = ?`, "updated", "a", 4).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Query(`DELETE FROM "events"."events" WHERE "stream" = ? AND "seq" = ?`, "a", 1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Query(`DELETE "tags" FROM "events"."events" WHERE "stream" = ? AND "seq" = ?`, "a", 2).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	events := memoryFetch(t, table.Query(`SELECT * FROM "events"."events" WHERE "stream" = 'a'`))
+	expected := []memoryEvent{{"a", 4, "updated", nil}, {"a", 3, "a-payload", []string{"a"}}, {"a", 2, "a-payload", nil}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %v but got %v", expected, events)
+	}
+
+	if err := table.Query(`INSERT INTO "events"."events" ("stream", "payload") VALUES (?, ?)`, "a", "x").Exec(); err == nil {
+		t.Error("expected an insert without the full primary key to fail")
+	}
+	if err := table.Query(`UPDATE "events"."events" SET "seq" = 1 WHERE "stream" = 'a' AND "seq" = 2`).Exec(); err == nil {
+		t.Error("expected an update of the primary key to fail")
+	}
+
+	if err := table.Query(`DELETE FROM "events"."events" WHERE "stream" = ?`, "a").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if !s.Iter(Statement{Stmt: `SELECT COUNT(*) FROM "events"."events"`}).Scan(&count) {
+		t.Fatal("expected a count")
+	}
+	if count != 6 {
+		t.Errorf("expected 6 rows but got %d", count)
+	}
+}
+
+func TestMemorySessionTTL(t *testing.T) {
+	s, table := memoryEventsTable(t)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	if err := table.Query(`INSERT INTO "events"."events" ("stream", "seq", "payload") VALUES (?, ?, ?) USING TTL ?`, "d", 1, "expiring", 10).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Query(`UPDATE "events"."events" USING TTL 5 SET "payload" = ? WHERE "stream" = ? AND "seq" = ?`, "expiring", "a", 1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(6 * time.Second)
+	if events := memoryFetch(t, table.Query(`SELECT * FROM "events"."events" WHERE "stream" = 'a' AND "seq" = 1`)); len(events) != 1 || events[0].Payload != "" {
+		t.Errorf("expected the payload to expire but got %v", events)
+	}
+	if events := memoryFetch(t, table.Query(`SELECT * FROM "events"."events" WHERE "stream" = 'd'`)); len(events) != 1 {
+		t.Errorf("expected the row to live but got %v", events)
+	}
+	now = now.Add(5 * time.Second)
+	if events := memoryFetch(t, table.Query(`SELECT * FROM "events"."events" WHERE "stream" = 'd'`)); len(events) != 0 {
+		t.Errorf("expected the row to expire but got %v", events)
+	}
+}
+
+func TestMemorySessionPaging(t *testing.T) {
+	_, table := memoryEventsTable(t)
+
+	var seqs []int
+	var state []byte
+	pages := 0
+	for {
+		iter := table.Query(`SELECT * FROM "events"."events" WHERE "stream" IN ('a', 'b')`).PageSize(4).PageState(state).Fetch()
+		for row, err := range iter.All() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			seqs = append(seqs, row.(*memoryEvent).Seq)
+		}
+		pages++
+		if state = iter.PageState(); state == nil {
+			break
+		}
+	}
+	if pages != 2 || !reflect.DeepEqual(seqs, []int{3, 2, 1, 3, 2, 1}) {
+		t.Errorf("expected 2 pages of %v but got %d of %v", []int{3, 2, 1, 3, 2, 1}, pages, seqs)
+	}
+}
+
+func TestMemorySessionSchema(t *testing.T) {
+	_, table := memoryEventsTable(t)
+	ks := table.Keyspace()
+
+	if err := ks.Create(map[string]interface{}{"class": "SimpleStrategy", "replication_factor": 1}, true); err == nil {
+		t.Error("expected creating an existing keyspace to fail")
+	}
+	if err := table.Create(); err == nil {
+		t.Error("expected creating an existing table to fail")
+	}
+	tables, err := ks.Tables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tables, []string{"events"}) {
+		t.Errorf("expected tables %v but got %v", []string{"events"}, tables)
+	}
+
+	if err := table.Drop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Query(`SELECT * FROM "events"."events"`).Fetch().Close(); err == nil {
+		t.Error("expected selecting from a dropped table to fail")
+	}
+	if err := ks.Drop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Drop(); err == nil {
+		t.Error("expected dropping a dropped keyspace to fail")
+	}
+}
+
+func TestPartitionToken(t *testing.T) {
+	// Tokens of int keys by Cassandra's Murmur3Partitioner
+	tests := map[int]int64{
+		1: -4069959284402364209,
+		2: -3248873570005575792,
+		3: 9010454139840013625,
+	}
+	for key, token := range tests {
+		data, err := marshal(typeInfo{typ: gocql.TypeInt, proto: 3}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := partitionToken([][]byte{data}); got != token {
+			t.Errorf("expected token %d for %d but got %d", token, key, got)
+		}
+	}
+}
+
+func TestMemorySessionBatch(t *testing.T) {
+	s, table := memoryEventsTable(t)
+	insert := `INSERT INTO "events"."events" ("stream", "seq", "payload") VALUES (?, ?, ?)`
+	update := `UPDATE "events"."events" SET "payload" = ? WHERE "stream" = ? AND "seq" = ?`
+
+	failing := Batch{Statements: []Statement{
+		{Stmt: insert, Values: []interface{}{"d", 1, "d-payload"}},
+		{Stmt: update, Values: []interface{}{"changed", "a", 1}},
+		{Stmt: update, Values: []interface{}{"changed", "a"}},
+	}}
+	if err := s.Batch(failing); err == nil {
+		t.Fatal("expected a batch with a missing value to fail")
+	}
+	if events := memoryFetch(t, table.Query(`SELECT * FROM "events"."events" WHERE "stream" IN ('a', 'd')`)); len(events) != 3 || events[2].Payload != "a-payload" {
+		t.Errorf("expected no statement of the failed batch to be applied but got %v", events)
+	}
+
+	if err := s.Batch(Batch{Statements: failing.Statements[:2]}); err != nil {
+		t.Fatal(err)
+	}
+	if events := memoryFetch(t, table.Query(`SELECT * FROM "events"."events" WHERE "stream" IN ('a', 'd')`)); len(events) != 4 || events[2].Payload != "changed" {
+		t.Errorf("expected the statements of the batch to be applied but got %v", events)
+	}
+
+	if err := s.Batch(Batch{Statements: []Statement{{Stmt: `SELECT * FROM "events"."events"`}}}); err == nil {
+		t.Error("expected a batch with a SELECT to fail")
+	}
+}
@@ -0,0 +1,72 @@
+package gocqltable
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy names the columns of struct fields, and of the row and range
+// keys of tables, from their keys as described by reflect.StructToMap. Column
+// names are always quoted in statements, so they are case sensitive.
+type NamingStrategy interface {
+	ColumnName(key string) string
+}
+
+// NamingStrategyFunc returns a NamingStrategy implemented by the function f.
+// Naming strategies can be compared, each call returns a distinct one.
+func NamingStrategyFunc(f func(key string) string) NamingStrategy {
+	return &namingFunc{f}
+}
+
+type namingFunc struct {
+	f func(key string) string
+}
+
+func (n *namingFunc) ColumnName(key string) string {
+	return n.f(key)
+}
+
+var (
+	// LowerCase names columns by their lower cased keys, UserID becomes
+	// userid. It is the default naming strategy.
+	LowerCase = NamingStrategyFunc(strings.ToLower)
+
+	// SnakeCase names columns by their snake cased keys, UserID becomes
+	// user_id and HTTPServer becomes http_server.
+	SnakeCase = NamingStrategyFunc(snakeCase)
+
+	// ExactCase names columns by their keys as they are, UserID stays UserID.
+	ExactCase = NamingStrategyFunc(func(key string) string { return key })
+)
+
+func snakeCase(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, c := range runes {
+		if unicode.IsUpper(c) && i > 0 && runes[i-1] != '_' {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextIsLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+// TableNamingStrategy returns the naming strategy of a table, if it has one as
+// Table does, and LowerCase otherwise.
+func TableNamingStrategy(t TableInterface) NamingStrategy {
+	if n, ok := t.(NamingTable); ok {
+		return namingOrDefault(n.NamingStrategy())
+	}
+	return LowerCase
+}
+
+func namingOrDefault(naming NamingStrategy) NamingStrategy {
+	if naming == nil {
+		return LowerCase
+	}
+	return naming
+}
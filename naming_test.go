@@ -0,0 +1,78 @@
+package gocqltable
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	var tests = []struct {
+		key, column string
+	}{
+		{"ID", "id"},
+		{"UserID", "user_id"},
+		{"userId", "user_id"},
+		{"HTTPServer", "http_server"},
+		{"Address2", "address2"},
+		{"user_id", "user_id"},
+		{"Created_At", "created_at"},
+	}
+	for _, test := range tests {
+		if column := SnakeCase.ColumnName(test.key); column != test.column {
+			t.Errorf("%s: expected %s but got %s", test.key, test.column, column)
+		}
+	}
+}
+
+func TestColumnDefinitionsNaming(t *testing.T) {
+	type Row struct {
+		UserID int64
+		Email  string `cql:"EmailAddress"`
+	}
+	var tests = []struct {
+		naming      NamingStrategy
+		definitions []string
+	}{
+		{LowerCase, []string{`"userid" bigint`, `"emailaddress" varchar`}},
+		{SnakeCase, []string{`"user_id" bigint`, `"email_address" varchar`}},
+		{ExactCase, []string{`"UserID" bigint`, `"EmailAddress" varchar`}},
+	}
+	for _, test := range tests {
+		definitions, err := columnDefinitions(Row{}, "", false, test.naming)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(definitions) != len(test.definitions) {
+			t.Fatalf("expected %v but got %v", test.definitions, definitions)
+		}
+		for i := range definitions {
+			if definitions[i] != test.definitions[i] {
+				t.Errorf("expected %s but got %s", test.definitions[i], definitions[i])
+			}
+		}
+	}
+
+	table := Keyspace{name: "ks", naming: SnakeCase}.NewTable("users", []string{"UserID"}, nil, Row{})
+	if names := table.columnNames(table.RowKeys()); names[0] != `"user_id"` {
+		t.Errorf(`expected the row key column "user_id" but got %s`, names[0])
+	}
+}
+
+type unnamedTable struct {
+	TableInterface
+}
+
+func TestTableNamingStrategy(t *testing.T) {
+	upper := NamingStrategyFunc(strings.ToUpper)
+	if upper == NamingStrategyFunc(strings.ToUpper) || upper != upper || upper == LowerCase {
+		t.Error("expected naming strategies to be compared by identity")
+	}
+
+	table := Keyspace{name: "ks", naming: upper}.NewTable("users", []string{"UserID"}, nil, struct{ UserID int64 }{})
+	if naming := TableNamingStrategy(table); naming != upper {
+		t.Errorf("expected the naming strategy of the table but got %v", naming)
+	}
+	if naming := TableNamingStrategy(unnamedTable{table}); naming != LowerCase {
+		t.Errorf("expected LowerCase for a table without a naming strategy but got %v", naming)
+	}
+}
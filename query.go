@@ -1,12 +1,11 @@
 package gocqltable
 
 import (
+	"context"
+	"iter"
 	"reflect"
-	"strings"
 
 	"github.com/gocql/gocql"
-
-	r "github.com/kristoiv/gocqltable/reflect"
 )
 
 type Query struct {
@@ -15,10 +14,59 @@ type Query struct {
 
 	Table   Table
 	Session *gocql.Session
+	// Backend is the Session the query runs through, that of Session if nil.
+	Backend Session
+
+	pageSize  int
+	pageState []byte
+	paged     bool
+
+	consistency       *gocql.Consistency
+	serialConsistency *gocql.SerialConsistency
+}
+
+// Consistency returns a copy of the query that runs at consistency c rather
+// than the default of its table, keyspace or session.
+func (q Query) Consistency(c gocql.Consistency) Query {
+	q.consistency = &c
+	return q
+}
+
+// SerialConsistency returns a copy of the query that runs the serial phase of
+// conditional updates at consistency c, which is gocql.Serial or
+// gocql.LocalSerial.
+func (q Query) SerialConsistency(c gocql.SerialConsistency) Query {
+	q.serialConsistency = &c
+	return q
+}
+
+// PageSize returns a copy of the query that fetches its rows in pages of n
+// rows.
+func (q Query) PageSize(n int) Query {
+	q.pageSize = n
+	return q
+}
+
+// PageState returns a copy of the query that fetches only the page of rows
+// that starts at state, which is the page state of the iterator of the page
+// before it, see Iterator.PageState. A nil state is the first page.
+func (q Query) PageState(state []byte) Query {
+	q.pageState = state
+	q.paged = true
+	return q
 }
 
 func (q Query) FetchRow() (interface{}, error) {
-	iter := q.Fetch()
+	return fetchRow(q.Fetch())
+}
+
+// FetchRowContext is like FetchRow, but stops when ctx is done, see
+// FetchContext.
+func (q Query) FetchRowContext(ctx context.Context) (interface{}, error) {
+	return fetchRow(q.FetchContext(ctx))
+}
+
+func fetchRow(iter *Iterator) (interface{}, error) {
 	row := iter.Next()
 	if err := iter.Close(); err != nil {
 		return nil, err
@@ -30,52 +78,181 @@ func (q Query) FetchRow() (interface{}, error) {
 }
 
 func (q Query) Fetch() *Iterator {
-	iter := q.Session.Query(q.Statement, q.Values...).Iter()
+	iter := q.backend().Iter(q.statement())
+	return &Iterator{
+		iter:   iter,
+		row:    q.Table.Row(),
+		naming: q.Table.NamingStrategy(),
+	}
+}
+
+// FetchContext is like Fetch, but the query is run with ctx: the driver gives
+// up on it when ctx is done, the iterator stops and Close returns the error of
+// ctx.
+func (q Query) FetchContext(ctx context.Context) *Iterator {
+	if err := ctx.Err(); err != nil {
+		return q.canceled(ctx, err)
+	}
+	stmt := q.statement()
+	stmt.Context = ctx
 	return &Iterator{
-		iter: iter,
-		row:  q.Table.Row(),
+		iter:   q.backend().Iter(stmt),
+		row:    q.Table.Row(),
+		naming: q.Table.NamingStrategy(),
+		ctx:    ctx,
+	}
+}
+
+// canceled returns an iterator without rows that fails with err.
+func (q Query) canceled(ctx context.Context, err error) *Iterator {
+	return &Iterator{
+		row:    q.Table.Row(),
+		naming: q.Table.NamingStrategy(),
+		ctx:    ctx,
+		err:    err,
 	}
 }
 
 func (q Query) Exec() error {
-	return q.Session.Query(q.Statement, q.Values...).Exec()
+	return q.backend().Exec(q.statement())
+}
+
+// ExecContext is like Exec, but the query is run with ctx, see FetchContext.
+func (q Query) ExecContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stmt := q.statement()
+	stmt.Context = ctx
+	return q.backend().Exec(stmt)
+}
+
+func (q Query) backend() Session {
+	if q.Backend != nil {
+		return q.Backend
+	}
+	return NewSession(q.Session)
+}
+
+// statement returns the statement of q that is run by its session.
+func (q Query) statement() Statement {
+	return Statement{
+		Stmt:   q.Statement,
+		Values: q.Values,
+
+		Consistency:       q.consistency,
+		SerialConsistency: q.serialConsistency,
+
+		PageSize:  q.pageSize,
+		PageState: q.pageState,
+		Paged:     q.paged,
+	}
 }
 
 type Iterator struct {
-	iter *gocql.Iter
-	row  interface{}
+	iter   Iter
+	row    interface{}
+	naming NamingStrategy
+
+	ctx     context.Context
+	scanner *rowScanner
+	err     error
 
-	done chan bool
+	// done stops the goroutine of RangeContext, which closes stopped when
+	// it returns.
+	done    chan bool
+	stopped chan bool
 }
 
 func (i *Iterator) Next() interface{} {
-	m := make(map[string]interface{})
-	if i.iter.MapScan(m) == false {
+	if i.iter == nil { // The query was canceled before it returned an iterator
 		return nil
 	}
+	if i.ctx != nil {
+		if err := i.ctx.Err(); err != nil {
+			i.err = err
+			return nil
+		}
+	}
 	t := reflect.TypeOf(i.row)
-	v := reflect.New(t)
-	r.MapToStruct(m, v.Interface())
-	r.MapToStruct(ucfirstKeys(m), v.Interface())
-	return v.Interface()
+	if i.scanner == nil {
+		scanner, err := newRowScanner(t, i.iter.Columns(), i.naming)
+		if err != nil {
+			i.err = err
+			return nil
+		}
+		i.scanner = scanner
+	}
+	row := i.scanner.newRow()
+	ok, err := i.scanner.scan(i.iter, row)
+	if err != nil {
+		i.err = err
+	}
+	if !ok {
+		return nil
+	}
+	return row
+}
+
+// All returns the rows of the iterator as a sequence to range over. Unlike
+// Range it runs no goroutine: an error ends the sequence as a nil row with the
+// error, and the iterator is closed when the loop ends, also when it breaks
+// early.
+func (i *Iterator) All() iter.Seq2[interface{}, error] {
+	return func(yield func(interface{}, error) bool) {
+		for {
+			row := i.Next()
+			if row == nil {
+				break
+			}
+			if !yield(row, nil) {
+				i.Close()
+				return
+			}
+		}
+		if err := i.Close(); err != nil {
+			yield(nil, err)
+		}
+	}
 }
 
+// Range returns a channel of the rows of the iterator, which is closed when
+// there are no more rows or when the iterator fails. The error is returned by
+// Close, which must be called if the channel is not read to its end or the
+// goroutine sending the rows is left blocked. Prefer All, which has neither
+// problem.
 func (i *Iterator) Range() <-chan interface{} {
-	rangeChan := make(chan interface{})
-	done := make(chan bool)
-	i.done = done
+	return i.RangeContext(i.context())
+}
+
+// RangeContext is like Range, but the channel is also closed when ctx is done,
+// after which Close returns the error of ctx.
+func (i *Iterator) RangeContext(ctx context.Context) <-chan interface{} {
+	return rangeRows(i, ctx, func(row interface{}) interface{} { return row })
+}
+
+// rangeRows sends the rows of i, converted by convert, on a channel as
+// RangeContext describes.
+func rangeRows[T any](i *Iterator, ctx context.Context, convert func(row interface{}) T) <-chan T {
+	i.ctx = ctx
+	rangeChan := make(chan T)
+	done, stopped := make(chan bool), make(chan bool)
+	i.done, i.stopped = done, stopped
 	go func() {
+		defer close(stopped)
+		defer close(rangeChan) // We clean up when we're done
 		for {
 			next := i.Next()
-			if next == nil { // We clean up when we're done
-				close(rangeChan)
+			if next == nil {
 				return
 			}
 			select {
-			case <-done: // We clean up when we're done
-				close(rangeChan)
+			case <-done:
+				return
+			case <-ctx.Done():
+				i.err = ctx.Err()
 				return
-			case rangeChan <- next:
+			case rangeChan <- convert(next):
 			}
 		}
 	}()
@@ -84,23 +261,62 @@ func (i *Iterator) Range() <-chan interface{} {
 
 func (i *Iterator) Close() error {
 	if done := i.done; done != nil {
-		close(i.done)
+		// the goroutine of RangeContext uses the iterator until it stops
+		close(done)
+		<-i.stopped
 		i.done = nil
 	}
-	return i.iter.Close()
+	if i.iter != nil {
+		if err := i.iter.Close(); err != nil {
+			return err
+		}
+	}
+	return i.err
 }
 
-func ucfirst(s string) string {
-	if len(s) < 2 {
-		return strings.ToUpper(s)
+// PageState returns the page state of the page after the current page of the
+// iterator, or nil if it is the last page. The next page is fetched with
+// Query.PageState, and Cursor encodes page states for clients.
+func (i *Iterator) PageState() []byte {
+	if i.iter == nil {
+		return nil
+	}
+	state := i.iter.PageState()
+	if len(state) == 0 {
+		return nil
+	}
+	return state
+}
+
+func (i *Iterator) context() context.Context {
+	if i.ctx == nil {
+		return context.Background()
+	}
+	return i.ctx
+}
+
+// rawColumn keeps the undecoded value of a column so it can be decoded once the
+// type it should be decoded into is known.
+type rawColumn struct {
+	data  []byte
+	elems []rawColumn
+}
+
+func (c *rawColumn) bytes() []byte {
+	if c.elems == nil {
+		return c.data
+	}
+	data := []byte{}
+	for _, elem := range c.elems {
+		data = appendBytes(data, elem.data)
 	}
-	return strings.ToUpper(s[0:1]) + s[1:]
+	return data
 }
 
-func ucfirstKeys(s map[string]interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
-	for key, value := range s {
-		result[ucfirst(key)] = value
+func (c *rawColumn) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	c.data = nil
+	if data != nil {
+		c.data = append(make([]byte, 0, len(data)), data...)
 	}
-	return result
+	return nil
 }
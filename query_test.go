@@ -0,0 +1,195 @@
+package gocqltable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func TestQueryContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The query has no session, so it fails if it is run at all
+	q := Query{Statement: "SELECT * FROM addresses"}
+	if err := q.ExecContext(ctx); err != context.Canceled {
+		t.Errorf("expected Exec to fail with %v but got %v", context.Canceled, err)
+	}
+	if _, err := q.FetchRowContext(ctx); err != context.Canceled {
+		t.Errorf("expected FetchRow to fail with %v but got %v", context.Canceled, err)
+	}
+
+	iter := q.FetchContext(ctx)
+	for row := range iter.Range() {
+		t.Errorf("expected no rows but got %v", row)
+	}
+	if err := iter.Close(); err != context.Canceled {
+		t.Errorf("expected the iterator to fail with %v but got %v", context.Canceled, err)
+	}
+}
+
+func TestIteratorAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var errs []error
+	for row, err := range (Query{}).FetchContext(ctx).All() {
+		if row != nil {
+			t.Errorf("expected no rows but got %v", row)
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || errs[0] != context.Canceled {
+		t.Errorf("expected the sequence to end with %v but got %v", context.Canceled, errs)
+	}
+}
+
+// recordingSession is a Session without rows that records the statements it
+// runs.
+type recordingSession struct {
+	stmts []Statement
+}
+
+func (s *recordingSession) Exec(stmt Statement) error {
+	s.stmts = append(s.stmts, stmt)
+	return nil
+}
+
+func (s *recordingSession) Iter(stmt Statement) Iter {
+	s.stmts = append(s.stmts, stmt)
+	return emptyIter{}
+}
+
+func (s *recordingSession) Batch(batch Batch) error {
+	s.stmts = append(s.stmts, batch.Statements...)
+	return nil
+}
+
+type emptyIter struct{}
+
+func (emptyIter) Columns() []gocql.ColumnInfo   { return nil }
+func (emptyIter) Scan(dest ...interface{}) bool { return false }
+func (emptyIter) PageState() []byte             { return nil }
+func (emptyIter) Close() error                  { return nil }
+
+func TestQuerySession(t *testing.T) {
+	session := &recordingSession{}
+	ks := Keyspace{name: "ks", session: session}
+	table := ks.NewTable("addresses", []string{"Zip"}, nil, Address{})
+
+	if err := table.Query("DELETE FROM addresses WHERE zip = ?", 1234).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Query("SELECT * FROM addresses").PageSize(10).PageState(nil).FetchRow(); err != gocql.ErrNotFound {
+		t.Errorf("expected %v but got %v", gocql.ErrNotFound, err)
+	}
+
+	if len(session.stmts) != 2 {
+		t.Fatalf("expected 2 statements but got %v", session.stmts)
+	}
+	if stmt := session.stmts[0]; stmt.Stmt != "DELETE FROM addresses WHERE zip = ?" || len(stmt.Values) != 1 || stmt.Values[0] != 1234 {
+		t.Errorf("expected the statement and values of the query but got %+v", stmt)
+	}
+	if stmt := session.stmts[1]; stmt.PageSize != 10 || !stmt.Paged || stmt.PageState != nil {
+		t.Errorf("expected the first page of 10 rows but got %+v", stmt)
+	}
+}
+
+func TestQueryConsistency(t *testing.T) {
+	ks := Keyspace{name: "ks", session: &recordingSession{}}
+	ks.SetConsistency(gocql.LocalQuorum)
+	table := ks.NewTable("addresses", []string{"Zip"}, nil, Address{})
+	table.SetSerialConsistency(gocql.LocalSerial)
+
+	stmt := table.Query("SELECT * FROM addresses").statement()
+	if c := stmt.Consistency; c == nil || *c != gocql.LocalQuorum {
+		t.Errorf("expected the consistency of the keyspace, %v, but got %v", gocql.LocalQuorum, c)
+	}
+	if c := stmt.SerialConsistency; c == nil || *c != gocql.LocalSerial {
+		t.Errorf("expected the serial consistency of the table, %v, but got %v", gocql.LocalSerial, c)
+	}
+
+	stmt = table.Query("SELECT * FROM addresses").Consistency(gocql.LocalOne).statement()
+	if c := stmt.Consistency; c == nil || *c != gocql.LocalOne {
+		t.Errorf("expected the consistency of the query, %v, but got %v", gocql.LocalOne, c)
+	}
+}
+
+func TestIteratorRangeContextCanceled(t *testing.T) {
+	_, table := memoryEventsTable(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	iter := table.Query(`SELECT * FROM "events"."events"`).FetchContext(ctx)
+	rows := iter.RangeContext(ctx)
+	if row := <-rows; row == nil {
+		t.Fatal("expected a row before the context is canceled")
+	}
+	cancel()
+	count := 1
+	for range rows {
+		count++
+	}
+	if count > 2 {
+		t.Errorf("expected the rows to stop once the context is canceled but got %d of 9", count)
+	}
+	if err := iter.Close(); err != context.Canceled {
+		t.Errorf("expected the iterator to fail with %v but got %v", context.Canceled, err)
+	}
+}
+
+func TestIteratorCloseWhileRanging(t *testing.T) {
+	_, table := memoryEventsTable(t)
+	iter := table.Query(`SELECT * FROM "events"."events"`).Fetch()
+	rows := iter.Range()
+	if row := <-rows; row == nil {
+		t.Fatal("expected a row")
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for row := range rows {
+		t.Errorf("expected no rows once the iterator is closed but got %v", row)
+	}
+}
+
+func TestKeyspaceBackend(t *testing.T) {
+	s := &gocql.Session{}
+	ks := NewKeyspace("ks")
+	ks.SetSession(s)
+	if ks.Session() != s || gocqlSessionOf(ks.Backend()) != s {
+		t.Errorf("expected the gocql session %p but got %p", s, ks.Session())
+	}
+	if q := ks.NewTable("addresses", []string{"Zip"}, nil, Address{}).Query("SELECT * FROM addresses"); q.Session != s {
+		t.Errorf("expected queries of the gocql session %p but got %p", s, q.Session)
+	}
+
+	backend := &recordingSession{}
+	ks.SetBackend(backend)
+	if ks.Session() != nil || ks.Backend() != backend {
+		t.Errorf("expected the backend without a gocql session but got %v and %p", ks.Backend(), ks.Session())
+	}
+	if err := ks.NewTable("addresses", []string{"Zip"}, nil, Address{}).Query("SELECT * FROM addresses").Exec(); err != nil || len(backend.stmts) != 1 {
+		t.Errorf("expected the query to run through the backend but got %v, %v", backend.stmts, err)
+	}
+
+	ks.SetSession(nil)
+	if ks.Backend() != defaultSession {
+		t.Errorf("expected the default session without a session but got %v", ks.Backend())
+	}
+}
+
+func TestBatchStatementOptions(t *testing.T) {
+	one := gocql.One
+	for _, stmt := range []Statement{
+		{Stmt: "DELETE FROM addresses", Consistency: &one},
+		{Stmt: "DELETE FROM addresses", PageSize: 10},
+		{Stmt: "DELETE FROM addresses", Context: context.Background()},
+	} {
+		// The batch has no gocql session, so it fails if it is run at all
+		if err := NewSession(nil).Batch(Batch{Statements: []Statement{stmt}}); err == nil {
+			t.Errorf("expected a batch of %+v to fail", stmt)
+		}
+	}
+}
@@ -1,17 +1,18 @@
 package recipes
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 	"reflect"
+	"sort"
 	"time"
 
+	"github.com/gocql/gocql"
 	"github.com/kristoiv/gocqltable"
-
-	r "github.com/kristoiv/gocqltable/reflect"
 )
 
 type RangeInterface interface {
@@ -27,19 +28,69 @@ type RangeInterface interface {
 	Fetch() (interface{}, error)
 }
 
+// PagingRangeInterface is implemented by ranges that can be fetched with a
+// context and a page at a time, as those of CRUD.Range are:
+//
+//	rows, next, err := crud.Range(id).(recipes.PagingRangeInterface).PageSize(100).FetchPage(state)
+type PagingRangeInterface interface {
+	RangeInterface
+	PageSize(n int) PagingRangeInterface
+	FetchContext(ctx context.Context) (interface{}, error)
+	FetchPage(state []byte) (rows interface{}, next []byte, err error)
+	FetchPageContext(ctx context.Context, state []byte) (rows interface{}, next []byte, err error)
+}
+
 type CRUD struct {
 	gocqltable.TableInterface
 }
 
+// Consistency returns a copy of the CRUD that runs its queries at consistency
+// c, for example userTable.Consistency(gocql.LocalOne).Get(email).
+func (t CRUD) Consistency(c gocql.Consistency) CRUD {
+	return CRUD{queryOption{t.TableInterface, func(q gocqltable.Query) gocqltable.Query {
+		return q.Consistency(c)
+	}}}
+}
+
+// SerialConsistency returns a copy of the CRUD that runs the serial phase of
+// its conditional updates at consistency c.
+func (t CRUD) SerialConsistency(c gocql.SerialConsistency) CRUD {
+	return CRUD{queryOption{t.TableInterface, func(q gocqltable.Query) gocqltable.Query {
+		return q.SerialConsistency(c)
+	}}}
+}
+
+// queryOption is a table that applies an option to its queries.
+type queryOption struct {
+	gocqltable.TableInterface
+	apply func(gocqltable.Query) gocqltable.Query
+}
+
+func (t queryOption) Query(statement string, values ...interface{}) gocqltable.Query {
+	return t.apply(t.TableInterface.Query(statement, values...))
+}
+
+func (t queryOption) NamingStrategy() gocqltable.NamingStrategy {
+	return gocqltable.TableNamingStrategy(t.TableInterface)
+}
+
 func (t CRUD) Insert(row interface{}) error {
-	return t.insert(row, nil)
+	return t.insert(context.Background(), row, nil)
+}
+
+func (t CRUD) InsertContext(ctx context.Context, row interface{}) error {
+	return t.insert(ctx, row, nil)
 }
 
 func (t CRUD) InsertWithTTL(row interface{}, ttl *time.Time) error {
-	return t.insert(row, ttl)
+	return t.insert(context.Background(), row, ttl)
+}
+
+func (t CRUD) InsertWithTTLContext(ctx context.Context, row interface{}, ttl *time.Time) error {
+	return t.insert(ctx, row, ttl)
 }
 
-func (t CRUD) insert(row interface{}, ttl *time.Time) error {
+func (t CRUD) insert(ctx context.Context, row interface{}, ttl *time.Time) error {
 
 	rowKeys := t.RowKeys()
 	rangeKeys := t.RangeKeys()
@@ -50,26 +101,37 @@ func (t CRUD) insert(row interface{}, ttl *time.Time) error {
 	// 	where = append(where, key+" = ?")
 	// }
 
-	m, ok := r.StructToMap(row)
-	if !ok {
-		panic("Unable to get map from struct during update")
+	m, err := gocqltable.RowToMap(row)
+	if err != nil {
+		return err
+	}
+	writable, err := gocqltable.RowToWritableMap(row)
+	if err != nil {
+		return err
 	}
 
 	fields := []string{}
 	placeholders := []string{}
 	vals := []interface{}{}
-	for key, value := range m {
+	for _, key := range sortedKeys(m) {
+		value := m[key]
 		// Check for empty row- or range keys
+		isAKey := false
 		for _, rowKey := range append(rowKeys, rangeKeys...) {
-			if strings.ToLower(key) == strings.ToLower(rowKey) {
-				if value == nil {
+			if t.sameColumn(key, rowKey) {
+				if isNull(value) {
 					return errors.New(fmt.Sprintf("Inserting row failed due to missing key value (for key %q)", rowKey))
 				}
+				isAKey = true
 				break
 			}
 		}
+		// Keys are always written, other fields only if writable
+		if _, ok := writable[key]; !ok && !isAKey {
+			continue
+		}
 		// Append to insertion slices
-		fields = append(fields, strings.ToLower(fmt.Sprintf("%q", key)))
+		fields = append(fields, column(t, key))
 		placeholders = append(placeholders, "?")
 		vals = append(vals, value)
 	}
@@ -80,7 +142,7 @@ func (t CRUD) insert(row interface{}, ttl *time.Time) error {
 		vals = append(vals, int(ttl.Sub(time.Now().UTC()).Seconds()+.5))
 	}
 
-	err := t.Query(fmt.Sprintf(`INSERT INTO %q.%q (%s) VALUES (%s) %s`, t.Keyspace().Name(), t.Name(), strings.Join(fields, ", "), strings.Join(placeholders, ", "), options), vals...).Exec()
+	err = t.Query(fmt.Sprintf(`INSERT INTO %q.%q (%s) VALUES (%s) %s`, t.Keyspace().Name(), t.Name(), strings.Join(fields, ", "), strings.Join(placeholders, ", "), options), vals...).ExecContext(ctx)
 	if err != nil {
 		for _, v := range vals {
 			log.Printf("%T %v", v, v)
@@ -93,6 +155,10 @@ func (t CRUD) insert(row interface{}, ttl *time.Time) error {
 }
 
 func (t CRUD) Get(ids ...interface{}) (interface{}, error) {
+	return t.GetContext(context.Background(), ids...)
+}
+
+func (t CRUD) GetContext(ctx context.Context, ids ...interface{}) (interface{}, error) {
 
 	rowKeys := t.RowKeys()
 	rangeKeys := t.RangeKeys()
@@ -103,10 +169,10 @@ func (t CRUD) Get(ids ...interface{}) (interface{}, error) {
 
 	where := []string{}
 	for _, key := range append(rowKeys, rangeKeys...) {
-		where = append(where, strings.ToLower(fmt.Sprintf("%q", key))+" = ?")
+		where = append(where, column(t, key)+" = ?")
 	}
 
-	row, err := t.Query(fmt.Sprintf(`SELECT * FROM %q.%q WHERE %s LIMIT 1`, t.Keyspace().Name(), t.Name(), strings.Join(where, " AND ")), ids...).FetchRow()
+	row, err := t.Query(fmt.Sprintf(`SELECT * FROM %q.%q WHERE %s LIMIT 1`, t.Keyspace().Name(), t.Name(), strings.Join(where, " AND ")), ids...).FetchRowContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -119,19 +185,27 @@ func (t CRUD) List(ids ...interface{}) (interface{}, error) {
 	return t.Range(ids...).Fetch()
 }
 
+func (t CRUD) ListContext(ctx context.Context, ids ...interface{}) (interface{}, error) {
+	return t.Range(ids...).(Range).FetchContext(ctx)
+}
+
 func (t CRUD) Update(row interface{}) error {
+	return t.UpdateContext(context.Background(), row)
+}
+
+func (t CRUD) UpdateContext(ctx context.Context, row interface{}) error {
 
 	rowKeys := t.RowKeys()
 	rangeKeys := t.RangeKeys()
 
 	where := []string{}
 	for _, key := range append(rowKeys, rangeKeys...) {
-		where = append(where, strings.ToLower(fmt.Sprintf("%q", key))+" = ?")
+		where = append(where, column(t, key)+" = ?")
 	}
 
-	m, ok := r.StructToMap(row)
-	if !ok {
-		panic("Unable to get map from struct during update")
+	m, err := gocqltable.RowToMap(row)
+	if err != nil {
+		return err
 	}
 
 	ids := []interface{}{}
@@ -140,23 +214,31 @@ func (t CRUD) Update(row interface{}) error {
 
 	for _, rowKey := range append(rowKeys, rangeKeys...) {
 		for key, value := range m {
-			if strings.ToLower(key) == strings.ToLower(rowKey) {
+			if t.sameColumn(key, rowKey) {
+				if isNull(value) {
+					return errors.New(fmt.Sprintf("Updating row failed due to missing key value (for key %q)", rowKey))
+				}
 				ids = append(ids, value)
 				break
 			}
 		}
 	}
 
-	for key, value := range m {
+	writable, err := gocqltable.RowToWritableMap(row)
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(writable) {
+		value := writable[key]
 		isAKey := false
 		for _, rowKey := range append(rowKeys, rangeKeys...) {
-			if strings.ToLower(key) == strings.ToLower(rowKey) {
+			if t.sameColumn(key, rowKey) {
 				isAKey = true
 				break
 			}
 		}
 		if !isAKey {
-			set = append(set, strings.ToLower(fmt.Sprintf("%q", key))+" = ?")
+			set = append(set, column(t, key)+" = ?")
 			vals = append(vals, value)
 		}
 	}
@@ -165,7 +247,11 @@ func (t CRUD) Update(row interface{}) error {
 		return errors.New(fmt.Sprintf("To few key-values to update row (%d of the required minimum of %d)", len(ids), len(rowKeys)+len(rangeKeys)))
 	}
 
-	err := t.Query(fmt.Sprintf(`UPDATE %q.%q SET %s WHERE %s`, t.Keyspace().Name(), t.Name(), strings.Join(set, ", "), strings.Join(where, " AND ")), append(vals, ids...)...).Exec()
+	if len(set) == 0 {
+		return errors.New("Updating row failed due to no writable values")
+	}
+
+	err = t.Query(fmt.Sprintf(`UPDATE %q.%q SET %s WHERE %s`, t.Keyspace().Name(), t.Name(), strings.Join(set, ", "), strings.Join(where, " AND ")), append(vals, ids...)...).ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -174,24 +260,31 @@ func (t CRUD) Update(row interface{}) error {
 }
 
 func (t CRUD) Delete(row interface{}) error {
+	return t.DeleteContext(context.Background(), row)
+}
+
+func (t CRUD) DeleteContext(ctx context.Context, row interface{}) error {
 
 	rowKeys := t.RowKeys()
 	rangeKeys := t.RangeKeys()
 
 	where := []string{}
 	for _, key := range append(rowKeys, rangeKeys...) {
-		where = append(where, strings.ToLower(fmt.Sprintf("%q", key))+" = ?")
+		where = append(where, column(t, key)+" = ?")
 	}
 
-	m, ok := r.StructToMap(row)
-	if !ok {
-		panic("Unable to get map from struct during update")
+	m, err := gocqltable.RowToMap(row)
+	if err != nil {
+		return err
 	}
 
 	ids := []interface{}{}
 	for _, rowKey := range append(rowKeys, rangeKeys...) {
 		for key, value := range m {
-			if strings.ToLower(key) == strings.ToLower(rowKey) {
+			if t.sameColumn(key, rowKey) {
+				if isNull(value) {
+					return errors.New(fmt.Sprintf("Deleting row failed due to missing key value (for key %q)", rowKey))
+				}
 				ids = append(ids, value)
 				break
 			}
@@ -202,7 +295,7 @@ func (t CRUD) Delete(row interface{}) error {
 		return errors.New(fmt.Sprintf("To few key-values to delete row (%d of the required %d)", len(ids), len(rowKeys)+len(rangeKeys)))
 	}
 
-	err := t.Query(fmt.Sprintf(`DELETE FROM %q.%q WHERE %s`, t.Keyspace().Name(), t.Name(), strings.Join(where, " AND ")), ids...).Exec()
+	err = t.Query(fmt.Sprintf(`DELETE FROM %q.%q WHERE %s`, t.Keyspace().Name(), t.Name(), strings.Join(where, " AND ")), ids...).ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -210,6 +303,43 @@ func (t CRUD) Delete(row interface{}) error {
 
 }
 
+// NamingStrategy returns the naming strategy of the table of the CRUD.
+func (t CRUD) NamingStrategy() gocqltable.NamingStrategy {
+	return gocqltable.TableNamingStrategy(t.TableInterface)
+}
+
+// column returns the quoted name of the column of a key by the naming strategy
+// of the table.
+func column(t gocqltable.TableInterface, key string) string {
+	return fmt.Sprintf("%q", gocqltable.TableNamingStrategy(t).ColumnName(key))
+}
+
+// sameColumn reports whether two keys name the same column.
+func (t CRUD) sameColumn(a, b string) bool {
+	naming := gocqltable.TableNamingStrategy(t)
+	return naming.ColumnName(a) == naming.ColumnName(b)
+}
+
+// sortedKeys returns the keys of a row map in order, so that the statements
+// built from it are the same for each row.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isNull reports whether a value is written as null, which nil pointers are.
+func isNull(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
 func (t CRUD) Range(ids ...interface{}) RangeInterface {
 	rowKeys := t.RowKeys()
 	rangeKeys := t.RangeKeys()
@@ -240,38 +370,39 @@ type Range struct {
 	order      string
 	limit      *int
 	filtering  bool
+	pageSize   int
 }
 
 func (r Range) LessThan(rangeKey string, value interface{}) RangeInterface {
-	r.where = append(r.where, fmt.Sprintf("%q", strings.ToLower(rangeKey))+" < ?")
+	r.where = append(r.where, column(r.table, rangeKey)+" < ?")
 	r.whereVals = append(r.whereVals, value)
 	r.filtering = true
 	return r
 }
 
 func (r Range) LessThanOrEqual(rangeKey string, value interface{}) RangeInterface {
-	r.where = append(r.where, fmt.Sprintf("%q", strings.ToLower(rangeKey))+" <= ?")
+	r.where = append(r.where, column(r.table, rangeKey)+" <= ?")
 	r.whereVals = append(r.whereVals, value)
 	r.filtering = true
 	return r
 }
 
 func (r Range) MoreThan(rangeKey string, value interface{}) RangeInterface {
-	r.where = append(r.where, fmt.Sprintf("%q", strings.ToLower(rangeKey))+" > ?")
+	r.where = append(r.where, column(r.table, rangeKey)+" > ?")
 	r.whereVals = append(r.whereVals, value)
 	r.filtering = true
 	return r
 }
 
 func (r Range) MoreThanOrEqual(rangeKey string, value interface{}) RangeInterface {
-	r.where = append(r.where, fmt.Sprintf("%q", strings.ToLower(rangeKey))+" >= ?")
+	r.where = append(r.where, column(r.table, rangeKey)+" >= ?")
 	r.whereVals = append(r.whereVals, value)
 	r.filtering = true
 	return r
 }
 
 func (r Range) EqualTo(rangeKey string, value interface{}) RangeInterface {
-	r.where = append(r.where, fmt.Sprintf("%q", strings.ToLower(rangeKey))+" = ?")
+	r.where = append(r.where, column(r.table, rangeKey)+" = ?")
 	r.whereVals = append(r.whereVals, value)
 	return r
 }
@@ -296,7 +427,34 @@ func (r Range) WhereIn(m map[string][]string) RangeInterface {
 	return r
 }
 
+func (r Range) PageSize(n int) PagingRangeInterface {
+	r.pageSize = n
+	return r
+}
+
 func (r Range) Fetch() (interface{}, error) {
+	return r.FetchContext(context.Background())
+}
+
+// FetchContext is like Fetch, but fails with the error of ctx if ctx is done
+// before all rows are fetched.
+func (r Range) FetchContext(ctx context.Context) (interface{}, error) {
+	rows, _, err := r.fetch(ctx, r.query())
+	return rows, err
+}
+
+// FetchPage fetches the page of rows that starts at state, see
+// gocqltable.Query.PageState, and returns them with the state of the next
+// page, which is nil after the last page.
+func (r Range) FetchPage(state []byte) (interface{}, []byte, error) {
+	return r.FetchPageContext(context.Background(), state)
+}
+
+func (r Range) FetchPageContext(ctx context.Context, state []byte) (interface{}, []byte, error) {
+	return r.fetch(ctx, r.query().PageState(state))
+}
+
+func (r Range) query() gocqltable.Query {
 	where := r.where
 	whereVals := r.whereVals
 	order := r.order
@@ -315,7 +473,7 @@ func (r Range) Fetch() (interface{}, error) {
 			if numberOfInClauses > 0 {
 				whereString = whereString + " AND"
 			}
-			whereString = fmt.Sprintf("%s %q IN (%v)", whereString, col, strings.Join(in, ", "))
+			whereString = fmt.Sprintf("%s %s IN (%v)", whereString, column(r.table, col), strings.Join(in, ", "))
 			numberOfInClauses++
 		}
 	}
@@ -349,17 +507,27 @@ func (r Range) Fetch() (interface{}, error) {
 		selectString = strings.Join(selectCols, ", ")
 	}
 	query := fmt.Sprintf(`SELECT %s FROM %q.%q %s %s %s %s`, selectString, r.table.Keyspace().Name(), r.table.Name(), whereString, orderString, limitString, filteringString)
-	iter := r.table.Query(query, whereVals...).Fetch()
+	return r.table.Query(query, whereVals...).PageSize(r.pageSize)
+}
 
-	result := reflect.Zero(reflect.SliceOf(reflect.PtrTo(reflect.TypeOf(r.table.Row())))) // Create a zero-value slice of pointers to our model type
-	for row := range iter.Range() {
-		result = reflect.Append(result, reflect.ValueOf(row)) // Append the rows to our slice
+func (r Range) fetch(ctx context.Context, query gocqltable.Query) (interface{}, []byte, error) {
+	iter := query.FetchContext(ctx)
+
+	rows := []interface{}{}
+	for row, err := range iter.All() {
+		// The iterator fails for rows that are not structs, so the type is
+		// valid once all rows are read
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, row)
 	}
 
-	if err := iter.Close(); err != nil {
-		return nil, err
+	result := reflect.Zero(reflect.SliceOf(reflect.PtrTo(reflect.TypeOf(r.table.Row())))) // Create a zero-value slice of pointers to our model type
+	for _, row := range rows {
+		result = reflect.Append(result, reflect.ValueOf(row)) // Append the rows to our slice
 	}
 
-	return result.Interface(), nil
+	return result.Interface(), iter.PageState(), nil
 
 }
@@ -0,0 +1,351 @@
+package recipes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+)
+
+type logEntry struct {
+	Host    string
+	Time    time.Time
+	Level   int
+	Message string
+}
+
+func logEntries(tb testing.TB) CRUD {
+	ks := gocqltable.NewKeyspace("logs")
+	ks.SetBackend(gocqltable.NewMemorySession())
+	if err := ks.Create(map[string]interface{}{"class": "SimpleStrategy", "replication_factor": 1}, true); err != nil {
+		tb.Fatal(err)
+	}
+	table := CRUD{ks.NewTable("entries", []string{"Host"}, []string{"Time"}, logEntry{})}
+	if err := table.Create(); err != nil {
+		tb.Fatal(err)
+	}
+	return table
+}
+
+func TestCRUD(t *testing.T) {
+	table := logEntries(t)
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		entry := logEntry{"web1", start.Add(time.Duration(i) * time.Minute), i % 2, "started"}
+		if err := table.Insert(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ttl := time.Now().Add(time.Hour)
+	if err := table.InsertWithTTL(logEntry{"web2", start, 0, "expiring"}, &ttl); err != nil {
+		t.Fatal(err)
+	}
+
+	row, err := table.Get("web1", start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := row.(*logEntry); entry.Level != 1 || entry.Message != "started" {
+		t.Errorf("expected the second entry but got %v", entry)
+	}
+
+	if err := table.Update(logEntry{"web1", start.Add(time.Minute), 2, "failed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Delete(logEntry{Host: "web1", Time: start}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Get("web1", start); err != gocql.ErrNotFound {
+		t.Errorf("expected the deleted entry to be %v but got %v", gocql.ErrNotFound, err)
+	}
+
+	rows, err := table.List("web1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, entry := range rows.([]*logEntry) {
+		messages = append(messages, entry.Message)
+	}
+	if expected := []string{"failed", "started", "started", "started"}; !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected %v but got %v", expected, messages)
+	}
+}
+
+type namedEntry struct {
+	UserID       int64
+	EmailAddress string
+	Visits       []string
+}
+
+func TestCRUDNaming(t *testing.T) {
+	for _, naming := range []gocqltable.NamingStrategy{gocqltable.SnakeCase, gocqltable.ExactCase} {
+		ks := gocqltable.NewKeyspace("ks")
+		ks.SetBackend(gocqltable.NewMemorySession())
+		ks.SetNamingStrategy(naming)
+		if err := ks.Create(map[string]interface{}{"class": "SimpleStrategy", "replication_factor": 1}, true); err != nil {
+			t.Fatal(err)
+		}
+		table := NewTypedCRUD(gocqltable.NewTypedTable[namedEntry](ks, "users", []string{"UserID"}, []string{"EmailAddress"}))
+		if err := table.Create(); err != nil {
+			t.Fatal(err)
+		}
+
+		entry := namedEntry{1, "a@example.com", []string{"home"}}
+		if err := table.Insert(entry); err != nil {
+			t.Fatalf("%s: %v", naming.ColumnName("UserID"), err)
+		}
+		got, err := table.Get(int64(1), "a@example.com")
+		if err != nil || !reflect.DeepEqual(*got, entry) {
+			t.Errorf("%s: expected %v but got %v, %v", naming.ColumnName("UserID"), entry, got, err)
+		}
+		entries, err := table.List(int64(1))
+		if err != nil || len(entries) != 1 || !reflect.DeepEqual(*entries[0], entry) {
+			t.Errorf("%s: expected [%v] but got %v, %v", naming.ColumnName("UserID"), entry, entries, err)
+		}
+	}
+}
+
+func TestCRUDContext(t *testing.T) {
+	table := logEntries(t)
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	entry := logEntry{"web1", start, 1, "started"}
+	if err := table.InsertContext(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	ttl := time.Now().Add(time.Hour)
+	if err := table.InsertWithTTLContext(ctx, logEntry{"web1", start.Add(time.Minute), 2, "expiring"}, &ttl); err != nil {
+		t.Fatal(err)
+	}
+	entry.Message = "failed"
+	if err := table.UpdateContext(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if row, err := table.GetContext(ctx, "web1", start); err != nil || row.(*logEntry).Message != "failed" {
+		t.Errorf("expected the updated entry but got %v, %v", row, err)
+	}
+	if err := table.DeleteContext(ctx, logEntry{Host: "web1", Time: start.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if rows, err := table.ListContext(ctx, "web1"); err != nil || len(rows.([]*logEntry)) != 1 {
+		t.Errorf("expected one entry but got %v, %v", rows, err)
+	}
+
+	cancel()
+	if err := table.InsertContext(ctx, logEntry{"web2", start, 1, "started"}); err != context.Canceled {
+		t.Errorf("expected Insert to fail with %v but got %v", context.Canceled, err)
+	}
+	if err := table.UpdateContext(ctx, entry); err != context.Canceled {
+		t.Errorf("expected Update to fail with %v but got %v", context.Canceled, err)
+	}
+	if err := table.DeleteContext(ctx, entry); err != context.Canceled {
+		t.Errorf("expected Delete to fail with %v but got %v", context.Canceled, err)
+	}
+	if _, err := table.GetContext(ctx, "web1", start); err != context.Canceled {
+		t.Errorf("expected Get to fail with %v but got %v", context.Canceled, err)
+	}
+	if _, err := table.ListContext(ctx, "web1"); err != context.Canceled {
+		t.Errorf("expected List to fail with %v but got %v", context.Canceled, err)
+	}
+	if _, _, err := table.Range("web1").(PagingRangeInterface).FetchPageContext(ctx, nil); err != context.Canceled {
+		t.Errorf("expected FetchPage to fail with %v but got %v", context.Canceled, err)
+	}
+
+	rows, err := table.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := rows.([]*logEntry); len(entries) != 1 || *entries[0] != entry {
+		t.Errorf("expected only %v but got %v", entry, entries)
+	}
+}
+
+type article struct {
+	ID    int
+	Title string
+	Draft string `cql:",omitempty"`
+	Views int64  `cql:",readonly"`
+}
+
+// statementLog is a session that logs the statements it runs.
+type statementLog struct {
+	gocqltable.Session
+	statements []gocqltable.Statement
+}
+
+func (s *statementLog) Exec(stmt gocqltable.Statement) error {
+	s.statements = append(s.statements, stmt)
+	return s.Session.Exec(stmt)
+}
+
+func (s *statementLog) Iter(stmt gocqltable.Statement) gocqltable.Iter {
+	s.statements = append(s.statements, stmt)
+	return s.Session.Iter(stmt)
+}
+
+func (s *statementLog) last() string {
+	return s.statements[len(s.statements)-1].Stmt
+}
+
+func articles(tb testing.TB) (CRUD, *statementLog) {
+	session := &statementLog{Session: gocqltable.NewMemorySession()}
+	ks := gocqltable.NewKeyspace("blog")
+	ks.SetBackend(session)
+	if err := ks.Create(map[string]interface{}{"class": "SimpleStrategy", "replication_factor": 1}, true); err != nil {
+		tb.Fatal(err)
+	}
+	table := CRUD{ks.NewTable("articles", []string{"ID"}, nil, article{})}
+	if err := table.Create(); err != nil {
+		tb.Fatal(err)
+	}
+	return table, session
+}
+
+func TestCRUDWritableFields(t *testing.T) {
+	table, session := articles(t)
+
+	if err := table.Insert(article{ID: 1, Title: "first", Views: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `INSERT INTO "blog"."articles" ("id", "title") VALUES (?, ?) `; session.last() != expected {
+		t.Errorf("expected %s but got %s", expected, session.last())
+	}
+	if err := table.Insert(article{ID: 2, Title: "second", Draft: "draft"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `INSERT INTO "blog"."articles" ("draft", "id", "title") VALUES (?, ?, ?) `; session.last() != expected {
+		t.Errorf("expected %s but got %s", expected, session.last())
+	}
+
+	if err := table.Update(article{ID: 1, Title: "changed", Views: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `UPDATE "blog"."articles" SET "title" = ? WHERE "id" = ?`; session.last() != expected {
+		t.Errorf("expected %s but got %s", expected, session.last())
+	}
+
+	row, err := table.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := row.(*article); a.Title != "changed" || a.Draft != "" || a.Views != 0 {
+		t.Errorf("expected only the title to be written but got %+v", a)
+	}
+}
+
+func TestCRUDConsistency(t *testing.T) {
+	session := &statementLog{Session: gocqltable.NewMemorySession()}
+	ks := gocqltable.NewKeyspace("logs")
+	ks.SetBackend(session)
+	ks.SetConsistency(gocql.Quorum)
+	if err := ks.Create(map[string]interface{}{"class": "SimpleStrategy", "replication_factor": 1}, true); err != nil {
+		t.Fatal(err)
+	}
+	entries := ks.NewTable("entries", []string{"Host"}, []string{"Time"}, logEntry{})
+	if err := entries.Create(); err != nil {
+		t.Fatal(err)
+	}
+	audits := ks.NewTable("audits", []string{"Host"}, []string{"Time"}, logEntry{})
+	audits.SetConsistency(gocql.LocalQuorum)
+	audits.SetSerialConsistency(gocql.LocalSerial)
+	if err := audits.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	consistency := func() (gocql.Consistency, gocql.SerialConsistency) {
+		stmt := session.statements[len(session.statements)-1]
+		var c gocql.Consistency
+		var serial gocql.SerialConsistency
+		if stmt.Consistency != nil {
+			c = *stmt.Consistency
+		}
+		if stmt.SerialConsistency != nil {
+			serial = *stmt.SerialConsistency
+		}
+		return c, serial
+	}
+	entry := logEntry{"web1", time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), 1, "started"}
+	tests := []struct {
+		name        string
+		run         func() error
+		consistency gocql.Consistency
+		serial      gocql.SerialConsistency
+	}{
+		{"keyspace", func() error { return CRUD{entries}.Insert(entry) }, gocql.Quorum, 0},
+		{"table", func() error { return CRUD{audits}.Insert(entry) }, gocql.LocalQuorum, gocql.LocalSerial},
+		{"get", func() error { _, err := CRUD{entries}.Consistency(gocql.One).Get("web1", entry.Time); return err }, gocql.One, 0},
+		{"list", func() error { _, err := CRUD{audits}.Consistency(gocql.All).List("web1"); return err }, gocql.All, gocql.LocalSerial},
+		{"update", func() error { return CRUD{entries}.SerialConsistency(gocql.Serial).Update(entry) }, gocql.Quorum, gocql.Serial},
+		{"delete", func() error { return CRUD{audits}.Consistency(gocql.Two).SerialConsistency(gocql.Serial).Delete(entry) }, gocql.Two, gocql.Serial},
+	}
+	for _, test := range tests {
+		if err := test.run(); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if c, serial := consistency(); c != test.consistency || serial != test.serial {
+			t.Errorf("%s: expected %v and %v but got %v and %v", test.name, test.consistency, test.serial, c, serial)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	table := logEntries(t)
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, host := range []string{"web1", "web2"} {
+		for i := 0; i < 5; i++ {
+			if err := table.Insert(logEntry{host, start.Add(time.Duration(i) * time.Minute), i, host}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	levels := func(rows interface{}) []int {
+		levels := []int{}
+		for _, entry := range rows.([]*logEntry) {
+			levels = append(levels, entry.Level)
+		}
+		return levels
+	}
+	tests := []struct {
+		name   string
+		rng    RangeInterface
+		levels []int
+	}{
+		{"range", table.Range("web1").MoreThan("Time", start).LessThanOrEqual("Time", start.Add(3*time.Minute)), []int{1, 2, 3}},
+		{"order", table.Range("web2").OrderBy(`"time" DESC`).Limit(2), []int{4, 3}},
+		{"filter", table.Range().EqualTo("Level", 4).MoreThan("Level", 0), []int{4, 4}},
+	}
+	for _, test := range tests {
+		rows, err := test.rng.Fetch()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := levels(rows); !reflect.DeepEqual(got, test.levels) {
+			t.Errorf("%s: expected %v but got %v", test.name, test.levels, got)
+		}
+	}
+
+	var got []int
+	var state []byte
+	for {
+		rows, next, err := table.Range("web1").(PagingRangeInterface).PageSize(2).FetchPage(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, levels(rows)...)
+		if state = next; state == nil {
+			break
+		}
+	}
+	if expected := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected pages of %v but got %v", expected, got)
+	}
+}
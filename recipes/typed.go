@@ -0,0 +1,184 @@
+package recipes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+)
+
+// TypedCRUD is a CRUD of rows of type T, which must be a struct. Rows are
+// returned as *T rather than interface{}.
+type TypedCRUD[T any] struct {
+	CRUD
+}
+
+// NewTypedCRUD returns a TypedCRUD of the table t.
+func NewTypedCRUD[T any](t gocqltable.TypedTable[T]) TypedCRUD[T] {
+	return TypedCRUD[T]{CRUD{t.Table}}
+}
+
+func (t TypedCRUD[T]) Consistency(c gocql.Consistency) TypedCRUD[T] {
+	return TypedCRUD[T]{t.CRUD.Consistency(c)}
+}
+
+func (t TypedCRUD[T]) SerialConsistency(c gocql.SerialConsistency) TypedCRUD[T] {
+	return TypedCRUD[T]{t.CRUD.SerialConsistency(c)}
+}
+
+func (t TypedCRUD[T]) Insert(row T) error {
+	return t.CRUD.Insert(row)
+}
+
+func (t TypedCRUD[T]) InsertContext(ctx context.Context, row T) error {
+	return t.CRUD.InsertContext(ctx, row)
+}
+
+func (t TypedCRUD[T]) InsertWithTTL(row T, ttl *time.Time) error {
+	return t.CRUD.InsertWithTTL(row, ttl)
+}
+
+func (t TypedCRUD[T]) InsertWithTTLContext(ctx context.Context, row T, ttl *time.Time) error {
+	return t.CRUD.InsertWithTTLContext(ctx, row, ttl)
+}
+
+func (t TypedCRUD[T]) Get(ids ...interface{}) (*T, error) {
+	return typedRow[T](t.CRUD.Get(ids...))
+}
+
+func (t TypedCRUD[T]) GetContext(ctx context.Context, ids ...interface{}) (*T, error) {
+	return typedRow[T](t.CRUD.GetContext(ctx, ids...))
+}
+
+func typedRow[T any](row interface{}, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return row.(*T), nil
+}
+
+func (t TypedCRUD[T]) List(ids ...interface{}) ([]*T, error) {
+	return t.Range(ids...).Fetch()
+}
+
+func (t TypedCRUD[T]) ListContext(ctx context.Context, ids ...interface{}) ([]*T, error) {
+	return t.Range(ids...).FetchContext(ctx)
+}
+
+func (t TypedCRUD[T]) Update(row T) error {
+	return t.CRUD.Update(row)
+}
+
+func (t TypedCRUD[T]) UpdateContext(ctx context.Context, row T) error {
+	return t.CRUD.UpdateContext(ctx, row)
+}
+
+func (t TypedCRUD[T]) Delete(row T) error {
+	return t.CRUD.Delete(row)
+}
+
+func (t TypedCRUD[T]) DeleteContext(ctx context.Context, row T) error {
+	return t.CRUD.DeleteContext(ctx, row)
+}
+
+func (t TypedCRUD[T]) Range(ids ...interface{}) TypedRange[T] {
+	return TypedRange[T]{t.CRUD.Range(ids...)}
+}
+
+// TypedRange is a RangeInterface of rows of type T.
+type TypedRange[T any] struct {
+	RangeInterface
+}
+
+func (r TypedRange[T]) LessThan(rangeKey string, value interface{}) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.LessThan(rangeKey, value)}
+}
+
+func (r TypedRange[T]) LessThanOrEqual(rangeKey string, value interface{}) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.LessThanOrEqual(rangeKey, value)}
+}
+
+func (r TypedRange[T]) MoreThan(rangeKey string, value interface{}) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.MoreThan(rangeKey, value)}
+}
+
+func (r TypedRange[T]) MoreThanOrEqual(rangeKey string, value interface{}) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.MoreThanOrEqual(rangeKey, value)}
+}
+
+func (r TypedRange[T]) EqualTo(rangeKey string, value interface{}) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.EqualTo(rangeKey, value)}
+}
+
+func (r TypedRange[T]) OrderBy(fieldAndDirection string) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.OrderBy(fieldAndDirection)}
+}
+
+func (r TypedRange[T]) Limit(l int) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.Limit(l)}
+}
+
+func (r TypedRange[T]) Select(s []string) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.Select(s)}
+}
+
+func (r TypedRange[T]) WhereIn(m map[string][]string) TypedRange[T] {
+	return TypedRange[T]{r.RangeInterface.WhereIn(m)}
+}
+
+// PageSize sets the page size of ranges that implement PagingRangeInterface,
+// other ranges are returned as they are.
+func (r TypedRange[T]) PageSize(n int) TypedRange[T] {
+	if paging, ok := r.RangeInterface.(PagingRangeInterface); ok {
+		return TypedRange[T]{paging.PageSize(n)}
+	}
+	return r
+}
+
+func (r TypedRange[T]) Fetch() ([]*T, error) {
+	return typedRows[T](r.RangeInterface.Fetch())
+}
+
+func (r TypedRange[T]) FetchContext(ctx context.Context) ([]*T, error) {
+	paging, err := r.paging()
+	if err != nil {
+		return nil, err
+	}
+	return typedRows[T](paging.FetchContext(ctx))
+}
+
+func (r TypedRange[T]) FetchPage(state []byte) ([]*T, []byte, error) {
+	return r.FetchPageContext(context.Background(), state)
+}
+
+func (r TypedRange[T]) FetchPageContext(ctx context.Context, state []byte) ([]*T, []byte, error) {
+	paging, err := r.paging()
+	if err != nil {
+		return nil, nil, err
+	}
+	return typedPage[T](paging.FetchPageContext(ctx, state))
+}
+
+func (r TypedRange[T]) paging() (PagingRangeInterface, error) {
+	paging, ok := r.RangeInterface.(PagingRangeInterface)
+	if !ok {
+		return nil, fmt.Errorf("Unable to fetch pages of %T as it does not implement PagingRangeInterface", r.RangeInterface)
+	}
+	return paging, nil
+}
+
+func typedPage[T any](rows interface{}, next []byte, err error) ([]*T, []byte, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows.([]*T), next, nil
+}
+
+func typedRows[T any](rows interface{}, err error) ([]*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return rows.([]*T), nil
+}
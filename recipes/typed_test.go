@@ -0,0 +1,99 @@
+package recipes
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+)
+
+func typedLogEntries(tb testing.TB) TypedCRUD[logEntry] {
+	ks := gocqltable.NewKeyspace("logs")
+	ks.SetBackend(gocqltable.NewMemorySession())
+	if err := ks.Create(map[string]interface{}{"class": "SimpleStrategy", "replication_factor": 1}, true); err != nil {
+		tb.Fatal(err)
+	}
+	table := NewTypedCRUD(gocqltable.NewTypedTable[logEntry](ks, "entries", []string{"Host"}, []string{"Time"}))
+	if err := table.Create(); err != nil {
+		tb.Fatal(err)
+	}
+	return table
+}
+
+func TestTypedCRUD(t *testing.T) {
+	table := typedLogEntries(t)
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		if err := table.Insert(logEntry{"web1", start.Add(time.Duration(i) * time.Minute), i, "started"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entry, err := table.Get("web1", start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (logEntry{"web1", start.Add(2 * time.Minute), 2, "started"}); *entry != expected {
+		t.Errorf("expected %v but got %v", expected, *entry)
+	}
+	if _, err := table.Get("web2", start); err != gocql.ErrNotFound {
+		t.Errorf("expected %v but got %v", gocql.ErrNotFound, err)
+	}
+
+	if err := table.Update(logEntry{"web1", start, 9, "failed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Delete(logEntry{Host: "web1", Time: start.Add(4 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := table.List("web1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels := func(entries []*logEntry) []int {
+		levels := []int{}
+		for _, entry := range entries {
+			levels = append(levels, entry.Level)
+		}
+		return levels
+	}
+	if expected := []int{9, 1, 2, 3}; !reflect.DeepEqual(levels(entries), expected) {
+		t.Errorf("expected %v but got %v", expected, levels(entries))
+	}
+
+	entries, err = table.Range("web1").MoreThan("Time", start).OrderBy(`"time" DESC`).Limit(2).Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{3, 2}; !reflect.DeepEqual(levels(entries), expected) {
+		t.Errorf("expected %v but got %v", expected, levels(entries))
+	}
+
+	var got []int
+	var state []byte
+	for {
+		entries, next, err := table.Range("web1").PageSize(3).FetchPage(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, levels(entries)...)
+		if state = next; state == nil {
+			break
+		}
+	}
+	if expected := []int{9, 1, 2, 3}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected pages of %v but got %v", expected, got)
+	}
+
+	if _, _, err := (TypedRange[logEntry]{unpagedRange{}}).FetchPage(nil); err == nil {
+		t.Error("expected fetching a page of a range that can not page to fail")
+	}
+}
+
+// unpagedRange is a RangeInterface that does not implement
+// PagingRangeInterface.
+type unpagedRange struct {
+	RangeInterface
+}
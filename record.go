@@ -0,0 +1,248 @@
+package gocqltable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+// ErrNotRecorded is returned by a Replayer for statements that it has no
+// recorded results of, or no more.
+var ErrNotRecorded = errors.New("Statement was not recorded")
+
+// Recorder is a Session that runs statements on another session and records
+// them, with their values and results, so that a Replayer can serve the
+// results without the session. Recording a staging cluster once lets tests
+// run offline from then on:
+//
+//	var update = flag.Bool("update", false, "record golden files")
+//
+//	if *update {
+//		recorder := gocqltable.NewRecorder(gocqltable.NewSession(s))
+//		defer recorder.Save("testdata/users.json")
+//		gocqltable.SetDefaultBackend(recorder)
+//	} else {
+//		replayer, err := gocqltable.LoadReplayer("testdata/users.json")
+//		...
+//		gocqltable.SetDefaultBackend(replayer)
+//	}
+//
+// The rows of queries are read in full when they are run. Values are recorded
+// in JSON, or in Go syntax if they have no JSON encoding, such as NaN. Values
+// computed from the time a statement runs never match on replay, such as the
+// TTL recipes.CRUD.InsertWithTTL computes from its expiry time.
+type Recorder struct {
+	session Session
+
+	mu         sync.Mutex
+	recordings []recording
+}
+
+// recording is a request to a session and its result, as stored in golden
+// files.
+type recording struct {
+	Request  recordedRequest `json:"request"`
+	Response recordedResult  `json:"response"`
+}
+
+type recordedRequest struct {
+	Op        string          `json:"op"` // exec, iter or batch
+	Statement string          `json:"statement,omitempty"`
+	Values    json.RawMessage `json:"values,omitempty"`
+
+	Consistency       *gocql.Consistency       `json:"consistency,omitempty"`
+	SerialConsistency *gocql.SerialConsistency `json:"serial_consistency,omitempty"`
+
+	PageSize   int               `json:"page_size,omitempty"`
+	PageState  []byte            `json:"page_state,omitempty"`
+	Paged      bool              `json:"paged,omitempty"`
+	Statements []recordedRequest `json:"statements,omitempty"` // of batches
+}
+
+type recordedResult struct {
+	Columns   []recordedColumn `json:"columns,omitempty"`
+	Rows      [][][]byte       `json:"rows,omitempty"`
+	PageState []byte           `json:"page_state,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// recordedColumn is a column, with its type named by its marshal class and
+// the protocol version it was encoded with.
+type recordedColumn struct {
+	Keyspace string `json:"keyspace"`
+	Table    string `json:"table"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Proto    byte   `json:"proto"`
+}
+
+// NewRecorder returns a Recorder of session.
+func NewRecorder(session Session) *Recorder {
+	return &Recorder{session: session}
+}
+
+func (r *Recorder) Exec(stmt Statement) error {
+	err := r.session.Exec(stmt)
+	r.record(newRecordedRequest("exec", stmt), recordedResult{Error: errorString(err)})
+	return err
+}
+
+func (r *Recorder) Iter(stmt Statement) Iter {
+	req := newRecordedRequest("iter", stmt)
+	iter := bufferIter(r.session.Iter(stmt))
+	result := recordedResult{Rows: iter.rows, PageState: iter.pageState, Error: errorString(iter.err)}
+	for _, column := range iter.columns {
+		result.Columns = append(result.Columns, recordedColumn{
+			Keyspace: column.Keyspace,
+			Table:    column.Table,
+			Name:     column.Name,
+			Type:     marshalClass(column.TypeInfo),
+			Proto:    column.TypeInfo.Version(),
+		})
+	}
+	r.record(req, result)
+	return iter
+}
+
+func (r *Recorder) Batch(batch Batch) error {
+	if err := batch.validate(); err != nil {
+		return err
+	}
+	req := recordedRequest{Op: "batch", Consistency: batch.Consistency}
+	for _, stmt := range batch.Statements {
+		req.Statements = append(req.Statements, newRecordedRequest("", stmt))
+	}
+	err := r.session.Batch(batch)
+	r.record(req, recordedResult{Error: errorString(err)})
+	return err
+}
+
+func (r *Recorder) record(req recordedRequest, result recordedResult) {
+	r.mu.Lock()
+	r.recordings = append(r.recordings, recording{req, result})
+	r.mu.Unlock()
+}
+
+// Save writes what has been recorded to the golden file at path.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.recordings, "", "\t")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("Unable to save recordings: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func newRecordedRequest(op string, stmt Statement) recordedRequest {
+	req := recordedRequest{
+		Op:        op,
+		Statement: stmt.Stmt,
+
+		Consistency:       stmt.Consistency,
+		SerialConsistency: stmt.SerialConsistency,
+
+		PageSize:  stmt.PageSize,
+		PageState: stmt.PageState,
+		Paged:     stmt.Paged,
+	}
+	if len(stmt.Values) > 0 {
+		values, err := json.Marshal(stmt.Values)
+		if err != nil {
+			// a JSON string, which no JSON array of values equals
+			values, _ = json.Marshal(fmt.Sprintf("%#v", stmt.Values))
+		}
+		req.Values = values
+	}
+	return req
+}
+
+// key returns the key of a request that identical requests share.
+func (req recordedRequest) key() string {
+	key, _ := json.Marshal(req)
+	return string(key)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Replayer is a Session that serves the results a Recorder recorded. Each
+// statement gets the results recorded for the same statement, values,
+// consistencies and paging, in the order they were recorded.
+type Replayer struct {
+	mu      sync.Mutex
+	results map[string][]recordedResult
+}
+
+// LoadReplayer returns a Replayer of the golden file at path.
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var recordings []recording
+	if err := json.Unmarshal(data, &recordings); err != nil {
+		return nil, fmt.Errorf("Unable to load recordings from %s: %w", path, err)
+	}
+	r := &Replayer{results: make(map[string][]recordedResult)}
+	for _, rec := range recordings {
+		key := rec.Request.key()
+		r.results[key] = append(r.results[key], rec.Response)
+	}
+	return r, nil
+}
+
+func (r *Replayer) Exec(stmt Statement) error {
+	return r.replay(newRecordedRequest("exec", stmt)).err
+}
+
+func (r *Replayer) Iter(stmt Statement) Iter {
+	return r.replay(newRecordedRequest("iter", stmt))
+}
+
+func (r *Replayer) Batch(batch Batch) error {
+	if err := batch.validate(); err != nil {
+		return err
+	}
+	req := recordedRequest{Op: "batch", Consistency: batch.Consistency}
+	for _, stmt := range batch.Statements {
+		req.Statements = append(req.Statements, newRecordedRequest("", stmt))
+	}
+	return r.replay(req).err
+}
+
+// replay returns the next result recorded for req.
+func (r *Replayer) replay(req recordedRequest) *memoryIter {
+	r.mu.Lock()
+	key := req.key()
+	results := r.results[key]
+	if len(results) == 0 {
+		r.mu.Unlock()
+		return &memoryIter{err: fmt.Errorf("%w: %q", ErrNotRecorded, req.Statement)}
+	}
+	result := results[0]
+	r.results[key] = results[1:]
+	r.mu.Unlock()
+
+	iter := &memoryIter{rows: result.Rows, pageState: result.PageState}
+	if result.Error != "" {
+		iter.err = errors.New(result.Error)
+	}
+	for _, column := range result.Columns {
+		iter.columns = append(iter.columns, gocql.ColumnInfo{
+			Keyspace: column.Keyspace,
+			Table:    column.Table,
+			Name:     column.Name,
+			TypeInfo: parseClass(column.Type, column.Proto),
+		})
+	}
+	return iter
+}
@@ -2,10 +2,15 @@
 package reflect
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	r "reflect"
+	"sort"
 	"strings"
 	"sync"
+
+	"github.com/gocql/gocql"
 )
 
 // StructToMap converts a struct to map. The object's default key string
@@ -21,73 +26,438 @@ import (
 //
 //   // Field appears in the resulting map as key "myName"
 //   Field int "myName"
+//
+// Options may follow the key in the "cql" tag, separated by commas. The "type"
+// option overrides the CQL type of the column:
+//
+//   // Field appears in the resulting map as key "id" and is a timeuuid column
+//   Field gocql.UUID `cql:"id,type=timeuuid"`
+//
+//   // Field appears in the resulting map as key "Field" and is an ascii column
+//   Field string `cql:",type=ascii"`
+//
+// The "tuple" option stores the structs in a field as tuples of their fields
+// rather than as user defined types:
+//
+//   // Field is a frozen<tuple<double, double>> column
+//   Field struct{ Lat, Long float64 } `cql:",tuple"`
+//
+// The fields of embedded structs, and of struct fields with the "inline"
+// option, appear in the resulting map as if they were fields of the outer
+// struct. Keys must be unique across all the flattened structs:
+//
+//   // Fields CreatedAt and UpdatedAt of Audit appear as keys "CreatedAt" and
+//   // "UpdatedAt"
+//   Audit
+//
+//   // Fields Lat and Long appear as keys "Lat" and "Long"
+//   Position struct{ Lat, Long float64 } `cql:",inline"`
+//
+// Embedded structs given a key in their tag are not flattened, and neither are
+// structs without exported fields, time.Time, types that implement
+// gocql.Marshaler and those registered with RegisterColumnType.
+//
+// Fields with the key "-" are ignored. The "omitempty" and "readonly" options
+// leave fields out of the map returned by StructToWritableMap, when they are
+// empty and always respectively. Fields are empty when they hold the zero value
+// of their type, such as a nil slice or an array of zeros, or a slice or map
+// without elements:
+//
+//   // Field is ignored
+//   Field int `cql:"-"`
+//
+//   // Field is only written when it is not zero
+//   Field time.Time `cql:",omitempty"`
+//
+//   // Field is read but never written
+//   Field int64 `cql:"views,readonly"`
+//
+// The second result is false if val is not a struct that can be mapped to
+// columns, see Validate.
 func StructToMap(val interface{}) (map[string]interface{}, bool) {
-	// indirect so function works with both structs and pointers to them
-	structVal := r.Indirect(r.ValueOf(val))
-	kind := structVal.Kind()
-	if kind != r.Struct {
+	structVal, sinfo, err := structInfoOf(val)
+	if err != nil {
 		return nil, false
 	}
-	sinfo := getStructInfo(structVal)
 	mapVal := make(map[string]interface{}, len(sinfo.FieldsList))
 	for _, field := range sinfo.FieldsList {
-		if structVal.Field(field.Num).CanInterface() {
-			mapVal[field.Key] = structVal.Field(field.Num).Interface()
+		if value, ok := fieldValue(structVal, field.Index); ok {
+			mapVal[field.Key] = value
 		}
 	}
 	return mapVal, true
 }
 
+// StructToWritableMap converts a struct to a map like StructToMap, leaving out
+// the fields that are not to be written: fields with the "readonly" option, and
+// fields with the "omitempty" option that are empty, see IsEmpty.
+func StructToWritableMap(val interface{}) (map[string]interface{}, bool) {
+	structVal, sinfo, err := structInfoOf(val)
+	if err != nil {
+		return nil, false
+	}
+	mapVal := make(map[string]interface{}, len(sinfo.FieldsList))
+	for _, field := range sinfo.FieldsList {
+		if field.ReadOnly {
+			continue
+		}
+		value, ok := fieldValue(structVal, field.Index)
+		if !ok || field.OmitEmpty && IsEmpty(value) {
+			continue
+		}
+		mapVal[field.Key] = value
+	}
+	return mapVal, true
+}
+
+// IsEmpty reports whether a field with the "omitempty" option holding value is
+// left out of the map returned by StructToWritableMap: value is nil, the zero
+// value of its type, or a slice or map without elements.
+func IsEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := r.ValueOf(value)
+	switch v.Kind() {
+	case r.Slice, r.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
 // MapToStruct converts a map to a struct. It is the inverse of the StructToMap
 // function. For details see StructToMap.
+//
+// Values are converted to the types of the fields they are assigned to when no
+// information is lost: between integer and floating point types that can hold
+// the value, between named types and their underlying types, from maps with
+// string keys to structs, and element by element for pointers, slices, arrays
+// and maps, where slices may be assigned to sets (maps with empty struct
+// values). A nil value sets its field to the zero value. An error describing
+// every value that could not be assigned is returned.
 func MapToStruct(m map[string]interface{}, struc interface{}) error {
-	val := r.Indirect(r.ValueOf(struc))
-	sinfo := getStructInfo(val)
+	val, sinfo, err := structInfoOf(struc)
+	if err != nil {
+		return err
+	}
+	if !val.CanSet() {
+		return fmt.Errorf("MapToStruct expects a pointer to a struct, got %T", struc)
+	}
+	var errs []string
 	for k, v := range m {
 		if info, ok := sinfo.FieldsMap[k]; ok {
-			structField := val.Field(info.Num)
-			if structField.Type().Name() == r.TypeOf(v).Name() {
-				structField.Set(r.ValueOf(v))
+			structField, ok := FieldByIndex(val, info.Index, true)
+			if !ok || !structField.CanSet() {
+				continue
+			}
+			if err := assign(structField, r.ValueOf(v)); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", k, err))
 			}
 		}
 	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("Unable to map values to %s (%s)", val.Type(), strings.Join(errs, ", "))
+	}
 	return nil
 }
 
+// assign sets dst to src, converting src as described by MapToStruct.
+func assign(dst, src r.Value) error {
+	if src.Kind() == r.Interface && !src.IsNil() {
+		src = src.Elem()
+	}
+	if !src.IsValid() || (src.Kind() == r.Interface || src.Kind() == r.Ptr) && src.IsNil() {
+		dst.Set(r.Zero(dst.Type()))
+		return nil
+	}
+	st, dt := src.Type(), dst.Type()
+	if st.AssignableTo(dt) {
+		dst.Set(src)
+		return nil
+	}
+	if src.Kind() == dst.Kind() && st.ConvertibleTo(dt) {
+		dst.Set(src.Convert(dt))
+		return nil
+	}
+	if src.Kind() == r.Ptr {
+		return assign(dst, src.Elem())
+	}
+
+	switch dst.Kind() {
+	case r.Ptr:
+		v := r.New(dt.Elem())
+		if err := assign(v.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(v)
+		return nil
+	case r.Int, r.Int8, r.Int16, r.Int32, r.Int64:
+		var n int64
+		switch src.Kind() {
+		case r.Int, r.Int8, r.Int16, r.Int32, r.Int64:
+			n = src.Int()
+		case r.Uint, r.Uint8, r.Uint16, r.Uint32, r.Uint64, r.Uintptr:
+			if src.Uint() > math.MaxInt64 {
+				return fmt.Errorf("value %v overflows %s", src, dt)
+			}
+			n = int64(src.Uint())
+		case r.Float32, r.Float64:
+			f := src.Float()
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return fmt.Errorf("value %v can not be represented by %s", src, dt)
+			}
+			n = int64(f)
+		default:
+			return fmt.Errorf("can not assign %s to %s", st, dt)
+		}
+		if dst.OverflowInt(n) {
+			return fmt.Errorf("value %v overflows %s", src, dt)
+		}
+		dst.SetInt(n)
+		return nil
+	case r.Uint, r.Uint8, r.Uint16, r.Uint32, r.Uint64, r.Uintptr:
+		var n uint64
+		switch src.Kind() {
+		case r.Int, r.Int8, r.Int16, r.Int32, r.Int64:
+			if src.Int() < 0 {
+				return fmt.Errorf("value %v overflows %s", src, dt)
+			}
+			n = uint64(src.Int())
+		case r.Uint, r.Uint8, r.Uint16, r.Uint32, r.Uint64, r.Uintptr:
+			n = src.Uint()
+		case r.Float32, r.Float64:
+			f := src.Float()
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return fmt.Errorf("value %v can not be represented by %s", src, dt)
+			}
+			n = uint64(f)
+		default:
+			return fmt.Errorf("can not assign %s to %s", st, dt)
+		}
+		if dst.OverflowUint(n) {
+			return fmt.Errorf("value %v overflows %s", src, dt)
+		}
+		dst.SetUint(n)
+		return nil
+	case r.Float32, r.Float64:
+		var f float64
+		switch src.Kind() {
+		case r.Int, r.Int8, r.Int16, r.Int32, r.Int64:
+			f = float64(src.Int())
+			if f >= math.MaxInt64 || int64(f) != src.Int() {
+				return fmt.Errorf("value %v can not be represented by %s", src, dt)
+			}
+		case r.Uint, r.Uint8, r.Uint16, r.Uint32, r.Uint64, r.Uintptr:
+			f = float64(src.Uint())
+			if f >= math.MaxUint64 || uint64(f) != src.Uint() {
+				return fmt.Errorf("value %v can not be represented by %s", src, dt)
+			}
+		case r.Float32, r.Float64:
+			f = src.Float()
+		default:
+			return fmt.Errorf("can not assign %s to %s", st, dt)
+		}
+		if dt.Kind() == r.Float32 && float64(float32(f)) != f && !math.IsNaN(f) {
+			return fmt.Errorf("value %v can not be represented by %s", src, dt)
+		}
+		dst.SetFloat(f)
+		return nil
+	case r.Slice:
+		if src.Kind() != r.Slice && src.Kind() != r.Array {
+			return fmt.Errorf("can not assign %s to %s", st, dt)
+		}
+		v := r.MakeSlice(dt, src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := assign(v.Index(i), src.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		dst.Set(v)
+		return nil
+	case r.Array:
+		if src.Kind() != r.Slice && src.Kind() != r.Array {
+			return fmt.Errorf("can not assign %s to %s", st, dt)
+		}
+		if src.Len() != dst.Len() {
+			return fmt.Errorf("can not assign %d elements to %s", src.Len(), dt)
+		}
+		v := r.New(dt).Elem()
+		for i := 0; i < src.Len(); i++ {
+			if err := assign(v.Index(i), src.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		dst.Set(v)
+		return nil
+	case r.Map:
+		v := r.MakeMap(dt)
+		switch {
+		case src.Kind() == r.Map:
+			for _, key := range src.MapKeys() {
+				k := r.New(dt.Key()).Elem()
+				if err := assign(k, key); err != nil {
+					return fmt.Errorf("key %v: %v", key, err)
+				}
+				e := r.New(dt.Elem()).Elem()
+				if err := assign(e, src.MapIndex(key)); err != nil {
+					return fmt.Errorf("element %v: %v", key, err)
+				}
+				v.SetMapIndex(k, e)
+			}
+		case (src.Kind() == r.Slice || src.Kind() == r.Array) && dt.Elem().Kind() == r.Struct && dt.Elem().NumField() == 0:
+			for i := 0; i < src.Len(); i++ {
+				k := r.New(dt.Key()).Elem()
+				if err := assign(k, src.Index(i)); err != nil {
+					return fmt.Errorf("element %d: %v", i, err)
+				}
+				v.SetMapIndex(k, r.Zero(dt.Elem()))
+			}
+		default:
+			return fmt.Errorf("can not assign %s to %s", st, dt)
+		}
+		dst.Set(v)
+		return nil
+	case r.Struct:
+		if src.Kind() != r.Map || st.Key().Kind() != r.String {
+			return fmt.Errorf("can not assign %s to %s", st, dt)
+		}
+		m := make(map[string]interface{}, src.Len())
+		for _, key := range src.MapKeys() {
+			m[key.String()] = src.MapIndex(key).Interface()
+		}
+		v := r.New(dt)
+		if err := MapToStruct(m, v.Interface()); err != nil {
+			return err
+		}
+		dst.Set(v.Elem())
+		return nil
+	}
+	return fmt.Errorf("can not assign %s to %s", st, dt)
+}
+
 // FieldsAndValues returns a list field names and a corresponing list of values
 // for the given struct. For details on how the field names are determined please
 // see StructToMap.
 func FieldsAndValues(val interface{}) ([]string, []interface{}, bool) {
-	// indirect so function works with both structs and pointers to them
-	structVal := r.Indirect(r.ValueOf(val))
-	kind := structVal.Kind()
-	if kind != r.Struct {
+	structVal, sinfo, err := structInfoOf(val)
+	if err != nil {
 		return nil, nil, false
 	}
-	sinfo := getStructInfo(structVal)
 	fields := make([]string, len(sinfo.FieldsList))
 	values := make([]interface{}, len(sinfo.FieldsList))
 	for i, info := range sinfo.FieldsList {
-		field := structVal.Field(info.Num)
 		fields[i] = info.Key
-		values[i] = field.Interface()
+		values[i], _ = fieldValue(structVal, info.Index)
 	}
 	return fields, values, true
 }
 
+// FieldByIndex returns the field of the struct v with the given index
+// sequence, as described by FieldInfo.Index. Nil pointers to embedded structs
+// are allocated if alloc is set, otherwise ok is false when one is reached.
+func FieldByIndex(v r.Value, index []int, alloc bool) (field r.Value, ok bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == r.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return r.Value{}, false
+				}
+				v.Set(r.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldValue returns the value of a field of the struct v, which is the zero
+// value of the field's type if it is part of a nil embedded struct.
+func fieldValue(v r.Value, index []int) (interface{}, bool) {
+	field, ok := FieldByIndex(v, index, false)
+	if !ok {
+		return r.Zero(v.Type().FieldByIndex(index).Type).Interface(), true
+	}
+	if !field.CanInterface() {
+		return nil, false
+	}
+	return field.Interface(), true
+}
+
+// Fields returns the FieldInfo of every field of the given struct in their
+// struct order. For details on how the fields are described please see
+// StructToMap.
+func Fields(val interface{}) ([]FieldInfo, bool) {
+	_, sinfo, err := structInfoOf(val)
+	if err != nil {
+		return nil, false
+	}
+	fields := make([]FieldInfo, len(sinfo.FieldsList))
+	copy(fields, sinfo.FieldsList)
+	return fields, true
+}
+
 var structMapMutex sync.RWMutex
 var structMap = make(map[r.Type]*structInfo)
 
-type fieldInfo struct {
+// FieldInfo describes how a struct field maps to a column.
+type FieldInfo struct {
+	// Key is the column name of the field.
 	Key string
-	Num int
+	// Index is the index sequence of the field for FieldByIndex. It is
+	// longer than one for the fields of embedded structs.
+	Index []int
+	// Type is the CQL type set by the "type" tag option, or empty if the
+	// type is to be derived from the field's Go type.
+	Type string
+	// Tuple is set by the "tuple" tag option, which stores structs in the
+	// field's type as tuples rather than user defined types.
+	Tuple bool
+	// OmitEmpty is set by the "omitempty" tag option, which skips writing
+	// the field when it is empty, see IsEmpty.
+	OmitEmpty bool
+	// ReadOnly is set by the "readonly" tag option, which never writes the
+	// field.
+	ReadOnly bool
 }
 
 type structInfo struct {
 	// FieldsMap is used to access fields by their key
-	FieldsMap map[string]fieldInfo
+	FieldsMap map[string]FieldInfo
 	// FieldsList allows iteration over the fields in their struct order.
-	FieldsList []fieldInfo
+	FieldsList []FieldInfo
+	// err is set if the struct can not be mapped to columns
+	err error
+}
+
+var (
+	// ErrNotAStruct is returned for values that are neither structs nor
+	// pointers to structs.
+	ErrNotAStruct = errors.New("not a struct")
+	// ErrDuplicateColumn is returned for structs where more than one field,
+	// including those of embedded structs, have the same key.
+	ErrDuplicateColumn = errors.New("duplicated column")
+)
+
+// Validate returns an error if val is not a struct, or a pointer to one, that
+// can be mapped to columns as described by StructToMap. The errors wrap
+// ErrNotAStruct and ErrDuplicateColumn.
+func Validate(val interface{}) error {
+	_, _, err := structInfoOf(val)
+	return err
+}
+
+// structInfoOf returns the struct val, or the struct pointed to by val, and its
+// structInfo.
+func structInfoOf(val interface{}) (r.Value, *structInfo, error) {
+	// indirect so functions work with both structs and pointers to them
+	v := r.Indirect(r.ValueOf(val))
+	if v.Kind() != r.Struct {
+		return v, nil, fmt.Errorf("Unable to map %T to columns: %w", val, ErrNotAStruct)
+	}
+	sinfo := getStructInfo(v)
+	return v, sinfo, sinfo.err
 }
 
 func getStructInfo(v r.Value) *structInfo {
@@ -99,38 +469,172 @@ func getStructInfo(v r.Value) *structInfo {
 		return sinfo
 	}
 
-	n := st.NumField()
-	fieldsMap := make(map[string]fieldInfo, n)
-	fieldsList := make([]fieldInfo, 0, n)
-	for i := 0; i != n; i++ {
-		field := st.Field(i)
-		info := fieldInfo{Num: i}
-		tag := field.Tag.Get("cql")
-		// If there is no cql specific tag and there are no other tags
-		// set the cql tag to the whole field tag
-		if tag == "" && strings.Index(string(field.Tag), ":") < 0 {
-			tag = string(field.Tag)
+	sinfo = &structInfo{
+		FieldsMap:  make(map[string]FieldInfo, st.NumField()),
+		FieldsList: make([]FieldInfo, 0, st.NumField()),
+	}
+	sinfo.addFields(st, st, nil, nil)
+	structMapMutex.Lock()
+	structMap[st] = sinfo
+	structMapMutex.Unlock()
+	return sinfo
+}
+
+// addFields adds the fields of the struct type t, found at the index sequence
+// index of the struct type st, flattening embedded and inline structs. Structs
+// already being flattened, listed in parents, are not flattened again.
+func (sinfo *structInfo) addFields(st, t r.Type, index []int, parents []r.Type) {
+	parents = append(parents, t)
+	for i := 0; i != t.NumField(); i++ {
+		field := t.Field(i)
+		info, inline, skip := ParseTag(field.Name, field.Tag, field.Anonymous)
+		if skip {
+			continue
 		}
-		if tag != "" {
-			info.Key = tag
-		} else {
-			info.Key = field.Name
+		info.Index = append(append([]int{}, index...), i)
+
+		if inline {
+			ft := field.Type
+			if ft.Kind() == r.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == r.Struct && !containsType(parents, ft) && !isColumnStruct(ft) {
+				sinfo.addFields(st, ft, info.Index, parents)
+				continue
+			}
 		}
 
-		if _, found = fieldsMap[info.Key]; found {
-			msg := fmt.Sprintf("Duplicated key '%s' in struct %s", info.Key, st.String())
-			panic(msg)
+		if _, found := sinfo.FieldsMap[info.Key]; found {
+			if sinfo.err == nil {
+				sinfo.err = fmt.Errorf("Duplicated key '%s' in struct %s: %w", info.Key, st.String(), ErrDuplicateColumn)
+			}
+			continue
 		}
 
-		fieldsList = append(fieldsList, info)
-		fieldsMap[info.Key] = info
-	}
-	sinfo = &structInfo{
-		fieldsMap,
-		fieldsList,
+		sinfo.FieldsList = append(sinfo.FieldsList, info)
+		sinfo.FieldsMap[info.Key] = info
 	}
+}
+
+var columnTypesMutex sync.RWMutex
+var columnTypes = map[string]bool{"time.Time": true}
+
+var marshalerType = r.TypeOf((*gocql.Marshaler)(nil)).Elem()
+
+// RegisterColumnType makes embedded and inline fields of the named struct type
+// t map to a single column rather than being flattened. Types that implement
+// gocql.Marshaler and time.Time are always single columns.
+func RegisterColumnType(t r.Type) {
+	RegisterColumnTypeName(t.PkgPath(), t.Name())
+}
+
+// RegisterColumnTypeName is RegisterColumnType for the struct type named name
+// of the package at path.
+func RegisterColumnTypeName(path, name string) {
+	columnTypesMutex.Lock()
+	columnTypes[path+"."+name] = true
+	columnTypesMutex.Unlock()
+
+	// forget the structs flattened before the type was registered
 	structMapMutex.Lock()
-	structMap[st] = sinfo
+	structMap = make(map[r.Type]*structInfo)
 	structMapMutex.Unlock()
-	return sinfo
+}
+
+// IsColumnStruct reports whether an embedded or inline field of the struct
+// type named name of the package at path is stored in a single column rather
+// than flattened. marshaler tells whether the type or a pointer to it
+// implements gocql.Marshaler, and exported whether it has exported fields.
+// It lets tools such as gocqltable-gen, which have no reflect.Type of the
+// struct, flatten structs like StructToMap does.
+func IsColumnStruct(path, name string, marshaler, exported bool) bool {
+	if marshaler || !exported {
+		return true
+	}
+	columnTypesMutex.RLock()
+	defer columnTypesMutex.RUnlock()
+	return name != "" && columnTypes[path+"."+name]
+}
+
+// isColumnStruct is IsColumnStruct for the struct type t.
+func isColumnStruct(t r.Type) bool {
+	marshaler := t.Implements(marshalerType) || r.PtrTo(t).Implements(marshalerType)
+	return IsColumnStruct(t.PkgPath(), t.Name(), marshaler, hasExportedFields(t))
+}
+
+func hasExportedFields(t r.Type) bool {
+	for i := 0; i != t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func containsType(types []r.Type, t r.Type) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTag parses the tag of a struct field as described by StructToMap into
+// a FieldInfo without an Index. skip is set for ignored fields, and inline for
+// fields whose struct, if they are a struct or a pointer to one, is flattened.
+func ParseTag(name string, tag r.StructTag, anonymous bool) (info FieldInfo, inline, skip bool) {
+	value := tag.Get("cql")
+	// If there is no cql specific tag and there are no other tags
+	// set the cql tag to the whole field tag
+	if value == "" && strings.Index(string(tag), ":") < 0 {
+		value = string(tag)
+	}
+	if value == "-" {
+		return info, false, true
+	}
+	options := splitTag(value)
+	if options[0] != "" {
+		info.Key = options[0]
+	} else {
+		info.Key = name
+	}
+	inline = anonymous && options[0] == ""
+	for _, option := range options[1:] {
+		option = strings.TrimSpace(option)
+		switch {
+		case strings.HasPrefix(option, "type="):
+			info.Type = strings.TrimPrefix(option, "type=")
+		case option == "tuple":
+			info.Tuple = true
+		case option == "inline":
+			inline = true
+		case option == "omitempty":
+			info.OmitEmpty = true
+		case option == "readonly":
+			info.ReadOnly = true
+		}
+	}
+	return info, inline, false
+}
+
+// splitTag splits a tag value on the commas that are not part of a
+// parameterized type such as map<text, int>.
+func splitTag(tag string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range tag {
+		switch c {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tag[start:])
 }
@@ -1,9 +1,14 @@
 package reflect
 
 import (
+	"errors"
+
 	"github.com/gocql/gocql"
 
+	r "reflect"
+	"sort"
 	"testing"
+	"time"
 )
 
 type Tweet struct {
@@ -177,3 +182,275 @@ func assertValuesEqual(t *testing.T, a, b []interface{}) {
 		}
 	}
 }
+
+type Event struct {
+	ID      gocql.UUID     `cql:"id,type=timeuuid"`
+	Code    string         `cql:",type=ascii"`
+	Counts  map[string]int `cql:"counts, type=map<ascii, int>"`
+	Payload []byte
+	Origin  struct{ X, Y int } `cql:"origin,tuple"`
+}
+
+func TestFields(t *testing.T) {
+	if _, ok := Fields("str"); ok {
+		t.Error("ok result from Fields when the val is a string")
+	}
+
+	fields, ok := Fields(&Event{})
+	if !ok {
+		t.Fatal("ok is false for an event")
+	}
+	expected := []FieldInfo{
+		{Key: "id", Index: []int{0}, Type: "timeuuid"},
+		{Key: "Code", Index: []int{1}, Type: "ascii"},
+		{Key: "counts", Index: []int{2}, Type: "map<ascii, int>"},
+		{Key: "Payload", Index: []int{3}},
+		{Key: "origin", Index: []int{4}, Tuple: true},
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("expected fields %v but got %v", expected, fields)
+	}
+	for i := range expected {
+		if !r.DeepEqual(fields[i], expected[i]) {
+			t.Errorf("expected field %v but got %v", expected[i], fields[i])
+		}
+	}
+}
+
+type Level int8
+
+type Reading struct {
+	Sensor  int64
+	Level   Level
+	Ratio   float64
+	Samples []int
+	Tags    map[string]struct{}
+	Limit   *int32
+	Origin  struct{ X, Y int }
+}
+
+func TestMapToStructConversions(t *testing.T) {
+	m := map[string]interface{}{
+		"Sensor":  42,
+		"Level":   int64(-3),
+		"Ratio":   float32(0.5),
+		"Samples": []int64{1, 2, 3},
+		"Tags":    []string{"a", "b"},
+		"Limit":   int64(7),
+		"Origin":  map[string]interface{}{"X": int64(1), "Y": 2},
+	}
+	reading := Reading{}
+	if err := MapToStruct(m, &reading); err != nil {
+		t.Fatal(err)
+	}
+	if reading.Sensor != 42 || reading.Level != -3 || reading.Ratio != 0.5 {
+		t.Errorf("unexpected scalars %v, %v, %v", reading.Sensor, reading.Level, reading.Ratio)
+	}
+	if len(reading.Samples) != 3 || reading.Samples[2] != 3 {
+		t.Errorf("expected samples [1 2 3] but got %v", reading.Samples)
+	}
+	if _, ok := reading.Tags["b"]; len(reading.Tags) != 2 || !ok {
+		t.Errorf("expected tags a and b but got %v", reading.Tags)
+	}
+	if reading.Limit == nil || *reading.Limit != 7 {
+		t.Errorf("expected limit 7 but got %v", reading.Limit)
+	}
+	if reading.Origin.X != 1 || reading.Origin.Y != 2 {
+		t.Errorf("expected origin {1 2} but got %v", reading.Origin)
+	}
+
+	m = map[string]interface{}{"Limit": nil}
+	if err := MapToStruct(m, &reading); err != nil {
+		t.Fatal(err)
+	}
+	if reading.Limit != nil {
+		t.Errorf("expected a nil limit but got %v", *reading.Limit)
+	}
+
+	var errorTests = []map[string]interface{}{
+		{"Level": 300},
+		{"Sensor": uint64(1 << 63)},
+		{"Sensor": 1.5},
+		{"Ratio": "0.5"},
+		{"Samples": []string{"1"}},
+	}
+	for _, m := range errorTests {
+		if err := MapToStruct(m, &Reading{}); err == nil {
+			t.Errorf("expected an error mapping %v", m)
+		}
+	}
+
+	if err := MapToStruct(map[string]interface{}{}, Reading{}); err == nil {
+		t.Error("expected an error mapping into a struct value")
+	}
+}
+
+type Audit struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time `cql:"updated_at"`
+}
+
+type Position struct {
+	Lat, Long float64
+}
+
+type Place struct {
+	Name string
+	Audit
+	*Position
+	Extra struct{ Note string } `cql:",inline"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	fields, _, ok := FieldsAndValues(Place{})
+	if !ok {
+		t.Fatal("ok is false for a place")
+	}
+	assertFieldsEqual(t, fields, []string{"Name", "CreatedAt", "updated_at", "Lat", "Long", "Note"})
+
+	now := time.Now()
+	place := Place{Name: "home", Audit: Audit{CreatedAt: now}}
+	m, _ := StructToMap(place)
+	if m["CreatedAt"] != now {
+		t.Errorf("Expected %v but got %v", now, m["CreatedAt"])
+	}
+	if m["Lat"] != 0.0 {
+		t.Errorf("Expected the zero value for a field of a nil embedded struct but got %v", m["Lat"])
+	}
+
+	m["Lat"] = 59.9
+	m["Note"] = "note"
+	place = Place{}
+	if err := MapToStruct(m, &place); err != nil {
+		t.Fatal(err)
+	}
+	if place.Name != "home" || !place.CreatedAt.Equal(now) || place.Extra.Note != "note" {
+		t.Errorf("unexpected place %+v", place)
+	}
+	if place.Position == nil || place.Lat != 59.9 {
+		t.Errorf("expected the embedded position to be allocated but got %v", place.Position)
+	}
+}
+
+type opaque struct{ value int }
+
+type Money struct{ Units, Nanos int64 }
+
+type Stamped struct {
+	Name string
+	time.Time
+	opaque
+	Money
+}
+
+func TestEmbeddedColumnTypes(t *testing.T) {
+	fields, _, ok := FieldsAndValues(Stamped{})
+	if !ok {
+		t.Fatal("ok is false for a stamped")
+	}
+	assertFieldsEqual(t, fields, []string{"Name", "Time", "opaque", "Units", "Nanos"})
+
+	RegisterColumnType(r.TypeOf(Money{}))
+	now := time.Now()
+	m, _ := StructToMap(Stamped{Name: "a", Time: now, Money: Money{Units: 1}})
+	if len(m) != 3 || m["Time"] != now || m["Money"] != (Money{Units: 1}) {
+		t.Errorf("expected Time and Money to be single columns but got %v", m)
+	}
+}
+
+func TestEmbeddedDuplicateKeys(t *testing.T) {
+	type Dup struct {
+		CreatedAt string
+		Audit
+	}
+	if _, ok := StructToMap(Dup{}); ok {
+		t.Error("ok result from StructToMap for a duplicated key across embedded structs")
+	}
+	if err := Validate(Dup{}); !errors.Is(err, ErrDuplicateColumn) {
+		t.Errorf("expected ErrDuplicateColumn but got %v", err)
+	}
+	if err := MapToStruct(map[string]interface{}{}, &Dup{}); !errors.Is(err, ErrDuplicateColumn) {
+		t.Errorf("expected ErrDuplicateColumn but got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(&Place{}); err != nil {
+		t.Error(err)
+	}
+	for _, val := range []interface{}{nil, "str", (*Place)(nil), []Place{}} {
+		if err := Validate(val); !errors.Is(err, ErrNotAStruct) {
+			t.Errorf("%T: expected ErrNotAStruct but got %v", val, err)
+		}
+	}
+}
+
+type Article struct {
+	ID        int
+	Title     string
+	Draft     string    `cql:"-"`
+	Published time.Time `cql:",omitempty"`
+	Views     int64     `cql:"views,readonly"`
+}
+
+type Profile struct {
+	ID     int
+	Tags   []string       `cql:",omitempty"`
+	Counts map[string]int `cql:",omitempty"`
+	Scores [2]int         `cql:",omitempty"`
+}
+
+func TestOmitEmpty(t *testing.T) {
+	tests := []struct {
+		profile  Profile
+		expected []string
+	}{
+		{Profile{ID: 1}, []string{"ID"}},
+		{Profile{ID: 1, Tags: []string{}, Counts: map[string]int{}}, []string{"ID"}},
+		{Profile{ID: 1, Tags: []string{""}, Counts: map[string]int{"": 0}}, []string{"Counts", "ID", "Tags"}},
+		{Profile{ID: 1, Scores: [2]int{0, 1}}, []string{"ID", "Scores"}},
+	}
+	for _, test := range tests {
+		m, _ := StructToWritableMap(test.profile)
+		var keys []string
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if !r.DeepEqual(keys, test.expected) {
+			t.Errorf("expected %v but got %v", test.expected, keys)
+		}
+	}
+}
+
+func TestFieldOptions(t *testing.T) {
+	article := Article{ID: 1, Title: "title", Draft: "draft", Views: 10}
+	fields, _, _ := FieldsAndValues(article)
+	assertFieldsEqual(t, fields, []string{"ID", "Title", "Published", "views"})
+
+	m, _ := StructToMap(article)
+	if _, ok := m["Draft"]; ok {
+		t.Error("expected an ignored field to be left out of the map")
+	}
+	if m["views"] != int64(10) {
+		t.Errorf("Expected 10 but got %v", m["views"])
+	}
+
+	m, _ = StructToWritableMap(article)
+	if len(m) != 2 || m["ID"] != 1 || m["Title"] != "title" {
+		t.Errorf("expected only ID and Title to be writable but got %v", m)
+	}
+
+	article.Published = time.Now()
+	m, _ = StructToWritableMap(&article)
+	if _, ok := m["Published"]; !ok {
+		t.Error("expected a non-zero omitempty field to be writable")
+	}
+
+	if err := MapToStruct(map[string]interface{}{"Draft": "x", "views": int64(3)}, &article); err != nil {
+		t.Fatal(err)
+	}
+	if article.Draft != "draft" || article.Views != 3 {
+		t.Errorf("unexpected article %+v", article)
+	}
+}
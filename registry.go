@@ -0,0 +1,97 @@
+package gocqltable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gocql/gocql"
+
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+// registeredType describes how a Go type registered with RegisterType is
+// stored.
+type registeredType struct {
+	cqlType   string
+	marshal   func(interface{}) (interface{}, error)
+	unmarshal func(interface{}) (interface{}, error)
+}
+
+var registeredTypesMutex sync.RWMutex
+var registeredTypes = make(map[reflect.Type]registeredType)
+
+// RegisterType registers the type of v to be stored in columns of the CQL type
+// cqlType, such as "bigint" or "frozen<list<text>>". Table.Create then uses
+// cqlType for fields of the type, which are not flattened when embedded.
+//
+// Before a value is written, marshal converts it into a value that the CQL type
+// can store, such as an int64 for a bigint. When a column is read it is decoded
+// into the default Go type of its CQL type, such as int64 for bigint, string
+// for text or []string for list<text>, and then converted by unmarshal. If
+// marshal or unmarshal is nil, the type or a pointer to it must implement
+// gocql.Marshaler or gocql.Unmarshaler respectively.
+func RegisterType(v interface{}, cqlType string, marshal, unmarshal func(interface{}) (interface{}, error)) error {
+	if v == nil {
+		return errors.New("Unable to register the type of a nil value")
+	}
+	if cqlType == "" {
+		return fmt.Errorf("Unable to register %T without a CQL type", v)
+	}
+	t := reflect.TypeOf(v)
+	marshalerType := reflect.TypeOf((*gocql.Marshaler)(nil)).Elem()
+	unmarshalerType := reflect.TypeOf((*gocql.Unmarshaler)(nil)).Elem()
+	if marshal == nil && !t.Implements(marshalerType) {
+		return fmt.Errorf("Unable to register %T without a marshal function as it does not implement gocql.Marshaler", v)
+	}
+	if unmarshal == nil && !reflect.PtrTo(t).Implements(unmarshalerType) {
+		return fmt.Errorf("Unable to register %T without an unmarshal function as it does not implement gocql.Unmarshaler", v)
+	}
+
+	registeredTypesMutex.Lock()
+	registeredTypes[t] = registeredType{cqlType, marshal, unmarshal}
+	registeredTypesMutex.Unlock()
+	if t.Kind() == reflect.Struct {
+		r.RegisterColumnType(t)
+	}
+	return nil
+}
+
+func lookupType(t reflect.Type) (registeredType, bool) {
+	registeredTypesMutex.RLock()
+	rt, found := registeredTypes[t]
+	registeredTypesMutex.RUnlock()
+	return rt, found
+}
+
+func (rt registeredType) marshalValue(info gocql.TypeInfo, value interface{}) ([]byte, error) {
+	v, err := rt.marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return marshal(info, v)
+}
+
+func (rt registeredType) unmarshalValue(info gocql.TypeInfo, data []byte, dst reflect.Value) error {
+	v := reflect.New(goType(info))
+	if err := unmarshal(info, data, v.Interface()); err != nil {
+		return err
+	}
+	result, err := rt.unmarshal(v.Elem().Interface())
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(result)
+	switch {
+	case !rv.IsValid():
+		dst.Set(reflect.Zero(dst.Type()))
+	case rv.Type().AssignableTo(dst.Type()):
+		dst.Set(rv)
+	case rv.Kind() == dst.Kind() && rv.Type().ConvertibleTo(dst.Type()):
+		dst.Set(rv.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("can not unmarshal %s into %s: unmarshal returned %T", typeName(info), dst.Type(), result)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package gocqltable
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+type Money struct {
+	Cents int64
+}
+
+type EmailAddress string
+
+func (e EmailAddress) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	return []byte(strings.ToLower(string(e))), nil
+}
+
+func (e *EmailAddress) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	*e = EmailAddress(data)
+	return nil
+}
+
+type Status int
+
+func TestRegisterType(t *testing.T) {
+	err := RegisterType(Money{}, "bigint", func(v interface{}) (interface{}, error) {
+		return v.(Money).Cents, nil
+	}, func(v interface{}) (interface{}, error) {
+		return Money{v.(int64)}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterType(EmailAddress(""), "text", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterType(Status(0), "ascii", nil, nil); err == nil {
+		t.Error("expected an error registering a type without hooks that does not implement gocql.Marshaler")
+	}
+
+	statuses := []string{"active", "disabled"}
+	err = RegisterType(Status(0), "ascii", func(v interface{}) (interface{}, error) {
+		return statuses[v.(Status)], nil
+	}, func(v interface{}) (interface{}, error) {
+		for i, status := range statuses {
+			if status == v.(string) {
+				return i, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown status %q", v)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		value interface{}
+		typ   string
+	}{
+		{Money{}, "bigint"},
+		{EmailAddress(""), "text"},
+		{map[Status]*Money{}, "map<ascii, bigint>"},
+	}
+	for _, test := range tests {
+		typ, err := stringTypeOf(test.value)
+		if err != nil {
+			t.Errorf("%T: %v", test.value, err)
+		} else if typ != test.typ {
+			t.Errorf("%T: expected %s but got %s", test.value, test.typ, typ)
+		}
+	}
+
+	bigint := typeInfo{typ: gocql.TypeBigInt, proto: 3}
+	data, err := marshal(bigint, &Money{1250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var money Money
+	if err := unmarshal(bigint, data, &money); err != nil {
+		t.Fatal(err)
+	}
+	if money.Cents != 1250 {
+		t.Errorf("expected 1250 cents but got %d", money.Cents)
+	}
+
+	ascii := typeInfo{typ: gocql.TypeAscii, proto: 3}
+	if data, err = marshal(ascii, Status(1)); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "disabled" {
+		t.Errorf("expected disabled but got %s", data)
+	}
+	var status Status
+	if err := unmarshal(ascii, data, &status); err != nil {
+		t.Fatal(err)
+	}
+	if status != 1 {
+		t.Errorf("expected status 1 but got %d", status)
+	}
+	if err := unmarshal(ascii, []byte("deleted"), &status); err == nil {
+		t.Error("expected an error unmarshalling an unknown status")
+	}
+
+	text := typeInfo{typ: gocql.TypeVarchar, proto: 3}
+	if data, err = marshal(text, EmailAddress("Me@Example.com")); err != nil {
+		t.Fatal(err)
+	}
+	var email EmailAddress
+	if err := unmarshal(text, data, &email); err != nil {
+		t.Fatal(err)
+	}
+	if email != "me@example.com" {
+		t.Errorf("expected me@example.com but got %s", email)
+	}
+}
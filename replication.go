@@ -0,0 +1,103 @@
+package gocqltable
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Replication is the replication strategy of a keyspace, see SimpleStrategy,
+// NetworkTopologyStrategy and ReplicationMap.
+type Replication interface {
+	// ReplicationOptions returns the options of the replication map of the
+	// strategy, which include its class.
+	ReplicationOptions() (map[string]string, error)
+}
+
+// SimpleStrategy places the replicas of a keyspace on the next nodes of the
+// ring, regardless of datacenters.
+type SimpleStrategy struct {
+	ReplicationFactor int
+}
+
+func (s SimpleStrategy) ReplicationOptions() (map[string]string, error) {
+	if s.ReplicationFactor < 1 {
+		return nil, fmt.Errorf("Invalid replication factor %d of SimpleStrategy", s.ReplicationFactor)
+	}
+	return map[string]string{
+		"class":              "SimpleStrategy",
+		"replication_factor": strconv.Itoa(s.ReplicationFactor),
+	}, nil
+}
+
+// NetworkTopologyStrategy places the replicas of a keyspace in datacenters,
+// by the replication factor of each datacenter:
+//
+//	gocqltable.NetworkTopologyStrategy{"dc1": 3, "dc2": 2}
+type NetworkTopologyStrategy map[string]int
+
+func (s NetworkTopologyStrategy) ReplicationOptions() (map[string]string, error) {
+	if len(s) == 0 {
+		return nil, errors.New("NetworkTopologyStrategy has no datacenters")
+	}
+	options := map[string]string{"class": "NetworkTopologyStrategy"}
+	for dc, factor := range s {
+		if dc == "" || dc == "class" {
+			return nil, fmt.Errorf("Invalid datacenter name %q of NetworkTopologyStrategy", dc)
+		}
+		if factor < 0 {
+			return nil, fmt.Errorf("Invalid replication factor %d of datacenter %q", factor, dc)
+		}
+		options[dc] = strconv.Itoa(factor)
+	}
+	return options, nil
+}
+
+// ReplicationMap is a replication map of any strategy, including its class,
+// as Keyspace.Create takes it. Its values are stored as text.
+type ReplicationMap map[string]interface{}
+
+func (m ReplicationMap) ReplicationOptions() (map[string]string, error) {
+	options := make(map[string]string, len(m))
+	for key, value := range m {
+		if value == nil {
+			return nil, fmt.Errorf("Replication option %q has no value", key)
+		}
+		options[key] = fmt.Sprint(value)
+	}
+	if options["class"] == "" {
+		return nil, errors.New("Replication map has no class")
+	}
+	return options, nil
+}
+
+// replicationLiteral renders the replication map of a strategy as a CQL map
+// literal, with the class first and the other options in order.
+func replicationLiteral(replication Replication) (string, error) {
+	if replication == nil {
+		return "", errors.New("Missing replication strategy")
+	}
+	options, err := replication.ReplicationOptions()
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		if key != "class" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	entries := []string{stringLiteral("class") + ": " + stringLiteral(options["class"])}
+	for _, key := range keys {
+		entries = append(entries, stringLiteral(key)+": "+stringLiteral(options[key]))
+	}
+	return "{" + strings.Join(entries, ", ") + "}", nil
+}
+
+// stringLiteral quotes s as a CQL string literal.
+func stringLiteral(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,70 @@
+package gocqltable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplicationLiteral(t *testing.T) {
+	tests := []struct {
+		replication Replication
+		literal     string
+	}{
+		{SimpleStrategy{3}, `{'class': 'SimpleStrategy', 'replication_factor': '3'}`},
+		{NetworkTopologyStrategy{"dc2": 2, "dc1": 3}, `{'class': 'NetworkTopologyStrategy', 'dc1': '3', 'dc2': '2'}`},
+		{ReplicationMap{"class": "SimpleStrategy", "replication_factor": 1}, `{'class': 'SimpleStrategy', 'replication_factor': '1'}`},
+		{ReplicationMap{"class": "com.example.Strategy", "option": `it's "quoted"`}, `{'class': 'com.example.Strategy', 'option': 'it''s "quoted"'}`},
+	}
+	for _, test := range tests {
+		literal, err := replicationLiteral(test.replication)
+		if err != nil {
+			t.Errorf("%v: %v", test.replication, err)
+		} else if literal != test.literal {
+			t.Errorf("expected %s but got %s", test.literal, literal)
+		}
+	}
+
+	for _, replication := range []Replication{nil, SimpleStrategy{}, NetworkTopologyStrategy{}, NetworkTopologyStrategy{"dc1": -1}, ReplicationMap{"replication_factor": 1}} {
+		if _, err := replicationLiteral(replication); err == nil {
+			t.Errorf("expected %v to be invalid", replication)
+		}
+	}
+}
+
+func TestKeyspaceCreateAndAlter(t *testing.T) {
+	s := NewMemorySession()
+	ks := NewKeyspace("replicated")
+	ks.SetBackend(s)
+
+	options := KeyspaceOptions{Replication: NetworkTopologyStrategy{"dc1": 3}}
+	if err := ks.CreateWithOptions(options); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.CreateWithOptions(options); err == nil {
+		t.Error("expected creating an existing keyspace to fail")
+	}
+	options.IfNotExists = true
+	if err := ks.CreateWithOptions(options); err != nil {
+		t.Errorf("expected IF NOT EXISTS to succeed but got %v", err)
+	}
+	if expected := map[string]string{"class": "NetworkTopologyStrategy", "dc1": "3"}; !reflect.DeepEqual(s.keyspaces["replicated"].replication, expected) {
+		t.Errorf("expected replication %v but got %v", expected, s.keyspaces["replicated"].replication)
+	}
+
+	durableWrites := false
+	if err := ks.Alter(KeyspaceOptions{DurableWrites: &durableWrites}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Alter(KeyspaceOptions{Replication: NetworkTopologyStrategy{"dc1": 3, "dc2": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if s.keyspaces["replicated"].durableWrites {
+		t.Error("expected durable writes to be off")
+	}
+	if expected := map[string]string{"class": "NetworkTopologyStrategy", "dc1": "3", "dc2": "1"}; !reflect.DeepEqual(s.keyspaces["replicated"].replication, expected) {
+		t.Errorf("expected replication %v but got %v", expected, s.keyspaces["replicated"].replication)
+	}
+	if err := ks.Alter(KeyspaceOptions{}); err == nil {
+		t.Error("expected altering nothing to fail")
+	}
+}
@@ -0,0 +1,169 @@
+package gocqltable
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gocql/gocql"
+
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+// rowScanner scans rows straight into the fields of row structs. Columns are
+// bound to the fields they map to once, when the scanner is created from the
+// columns of a query. The fields are found by the RowMapper of the row type if
+// one is registered, and by reflection otherwise.
+type rowScanner struct {
+	rowType  reflect.Type
+	mapper   RowMapper
+	bindings []*columnBinding
+	dest     []interface{}
+}
+
+// columnBinding decodes a column into the row field it is bound to. It is the
+// scan destination of its column.
+type columnBinding struct {
+	info  gocql.TypeInfo
+	key   string
+	pos   int // position of the field in the fields of the row, -1 if none
+	index []int
+
+	// raw keeps the elements of tuples, which gocql scans an element at a
+	// time, until the whole tuple can be decoded.
+	raw *rawColumn
+
+	// row and field point to the row being scanned and the bound field of
+	// it, field is nil if the field can not be set.
+	row   interface{}
+	field interface{}
+}
+
+func newRowScanner(rowType reflect.Type, columns []gocql.ColumnInfo, naming NamingStrategy) (*rowScanner, error) {
+	naming = namingOrDefault(naming)
+	s := &rowScanner{rowType: rowType}
+
+	var infos []r.FieldInfo
+	if mapper, ok := lookupRowMapper(rowType); ok {
+		s.mapper = mapper
+		infos = mapper.Fields()
+	} else {
+		if rowType == nil {
+			return nil, fmt.Errorf("Unable to scan rows into <nil>: %w", r.ErrNotAStruct)
+		}
+		row := reflect.Zero(rowType).Interface()
+		if err := r.Validate(row); err != nil {
+			return nil, err
+		}
+		infos, _ = r.Fields(row)
+	}
+	fields := make(map[string]int, len(infos))
+	for pos, info := range infos {
+		if s.mapper != nil || rowType.FieldByIndex(info.Index).PkgPath == "" {
+			fields[naming.ColumnName(info.Key)] = pos
+		}
+	}
+
+	for _, column := range columns {
+		b := &columnBinding{info: column.TypeInfo, pos: -1}
+		if pos, ok := fields[column.Name]; ok {
+			b.key = infos[pos].Key
+			b.pos = pos
+			b.index = infos[pos].Index
+		}
+		s.bindings = append(s.bindings, b)
+
+		// gocql scans tuples an element at a time
+		if n, ok := tupleSize(column.TypeInfo); ok {
+			b.raw = &rawColumn{elems: make([]rawColumn, n)}
+			for e := range b.raw.elems {
+				s.dest = append(s.dest, &b.raw.elems[e])
+			}
+			continue
+		}
+		if b.pos < 0 {
+			s.dest = append(s.dest, nil) // skipped by gocql
+			continue
+		}
+		s.dest = append(s.dest, b)
+	}
+	return s, nil
+}
+
+// tupleSize returns the number of elements of a tuple column, which gocql
+// scans into as many destinations.
+func tupleSize(info gocql.TypeInfo) (int, bool) {
+	switch info := info.(type) {
+	case gocql.TupleTypeInfo:
+		return len(info.Elems), true
+	case typeInfo:
+		return len(info.elems), info.typ == gocql.TypeTuple
+	}
+	return 0, false
+}
+
+// newRow returns a pointer to a new row struct.
+func (s *rowScanner) newRow() interface{} {
+	if s.mapper != nil {
+		return s.mapper.New()
+	}
+	return reflect.New(s.rowType).Interface()
+}
+
+// bind binds the columns to the fields of row, a pointer to a row struct.
+func (s *rowScanner) bind(row interface{}) {
+	if s.mapper != nil {
+		pointers := s.mapper.Pointers(row)
+		for _, b := range s.bindings {
+			if b.pos >= 0 {
+				b.row, b.field = row, pointers[b.pos]
+			}
+		}
+		return
+	}
+	v := reflect.ValueOf(row).Elem()
+	for _, b := range s.bindings {
+		if b.pos >= 0 {
+			b.row, b.field = row, nil
+			if field, ok := r.FieldByIndex(v, b.index, true); ok && field.CanSet() {
+				b.field = field.Addr().Interface()
+			}
+		}
+	}
+}
+
+// scan scans the next row of iter into row, a pointer to a row struct.
+func (s *rowScanner) scan(iter Iter, row interface{}) (bool, error) {
+	s.bind(row)
+	if !iter.Scan(s.dest...) {
+		return false, nil
+	}
+	for _, b := range s.bindings {
+		if b.raw != nil && b.pos >= 0 {
+			if err := b.decode(b.raw.bytes()); err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func (b *columnBinding) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	return b.decode(data)
+}
+
+func (b *columnBinding) decode(data []byte) error {
+	if b.field == nil {
+		return nil
+	}
+	if err := unmarshal(b.info, data, b.field); err == nil {
+		return nil
+	}
+	// Fall back to decoding into the column's default type and converting
+	// that as MapToStruct does, such as from an int column into an int64
+	v := newValue(b.info)
+	if err := unmarshal(b.info, data, v); err != nil {
+		return err
+	}
+	m := map[string]interface{}{b.key: reflect.Indirect(reflect.ValueOf(v)).Interface()}
+	return r.MapToStruct(m, b.row)
+}
@@ -0,0 +1,130 @@
+package gocqltable
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+type scanRow struct {
+	ID      int64
+	Name    string
+	Score   int32
+	Visits  int64 `cql:"visits"`
+	Ignored string
+	scanAudit
+}
+
+type scanAudit struct {
+	Created int64
+}
+
+var scanColumns = []gocql.ColumnInfo{
+	{Name: "id", TypeInfo: customType("LongType")},
+	{Name: "name", TypeInfo: customType("UTF8Type")},
+	{Name: "score", TypeInfo: customType("Int32Type")},
+	{Name: "visits", TypeInfo: customType("Int32Type")}, // converted to int64
+	{Name: "extra", TypeInfo: customType("UTF8Type")},   // maps to no field
+	{Name: "created", TypeInfo: customType("LongType")},
+}
+
+func scanData(tb testing.TB) [][]byte {
+	values := []interface{}{int64(42), "name", int32(7), int32(3), "extra", int64(1000)}
+	data := make([][]byte, len(values))
+	for i, v := range values {
+		var err error
+		if data[i], err = marshal(scanColumns[i].TypeInfo, v); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return data
+}
+
+// scanInto scans a row of data into row like gocql's Iter.Scan does.
+func scanInto(s *rowScanner, data [][]byte, row interface{}) error {
+	s.bind(row)
+	for i, dest := range s.dest {
+		if dest == nil {
+			continue
+		}
+		if err := dest.(gocql.Unmarshaler).UnmarshalCQL(scanColumns[i].TypeInfo, data[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapScan scans a row of data into row as Iterator.Next did before rows were
+// scanned into their fields: every column is decoded into the default Go type
+// of its CQL type and put in a map by its name, as gocql's Iter.MapScan does,
+// and the map is then assigned to the row twice by MapToStruct, as it is and
+// with the first letters of its keys upper cased. Errors are ignored as they
+// were then.
+func mapScan(data [][]byte, row interface{}) {
+	m := make(map[string]interface{}, len(scanColumns))
+	for i, column := range scanColumns {
+		v := newValue(column.TypeInfo)
+		unmarshal(column.TypeInfo, data[i], v)
+		m[column.Name] = reflect.Indirect(reflect.ValueOf(v)).Interface()
+	}
+	r.MapToStruct(m, row)
+	ucfirst := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		ucfirst[strings.ToUpper(key[:1])+key[1:]] = value
+	}
+	r.MapToStruct(ucfirst, row)
+}
+
+func TestRowScanner(t *testing.T) {
+	data := scanData(t)
+	s, err := newRowScanner(reflect.TypeOf(scanRow{}), scanColumns, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.dest[4] != nil {
+		t.Errorf("expected no scan destination for a column without a field")
+	}
+
+	var row scanRow
+	if err := scanInto(s, data, &row); err != nil {
+		t.Fatal(err)
+	}
+	expected := scanRow{ID: 42, Name: "name", Score: 7, Visits: 3, scanAudit: scanAudit{1000}}
+	if row != expected {
+		t.Errorf("expected %+v but got %+v", expected, row)
+	}
+
+	s.dest[2].(gocql.Unmarshaler).UnmarshalCQL(scanColumns[2].TypeInfo, nil)
+	if row.Score != 0 {
+		t.Errorf("expected a null column to zero its field but got %d", row.Score)
+	}
+}
+
+func BenchmarkRowScanner(b *testing.B) {
+	data := scanData(b)
+	s, err := newRowScanner(reflect.TypeOf(scanRow{}), scanColumns, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := scanInto(s, data, s.newRow()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMapScan(b *testing.B) {
+	data := scanData(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := reflect.New(reflect.TypeOf(scanRow{}))
+		mapScan(data, v.Interface())
+	}
+}
@@ -0,0 +1,187 @@
+package gocqltable
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of columns of TableMetadata.
+const (
+	PartitionKeyColumn = "partition_key"
+	ClusteringColumn   = "clustering"
+	RegularColumn      = "regular"
+	StaticColumn       = "static"
+)
+
+// TableMetadata describes a table as the schema tables of Cassandra do, see
+// Keyspace.TablesMetadata.
+type TableMetadata struct {
+	Keyspace          string
+	Name              string
+	Comment           string
+	DefaultTimeToLive int // in seconds, 0 if rows do not expire
+
+	// PartitionKey and ClusteringColumns are the columns of the primary key
+	// in order, and Columns are all columns: those of the primary key first
+	// and the others by name.
+	PartitionKey      []ColumnMetadata
+	ClusteringColumns []ColumnMetadata
+	Columns           []ColumnMetadata
+}
+
+// ColumnMetadata describes a column of a table.
+type ColumnMetadata struct {
+	Name string
+	Type string // in CQL, such as "map<text, int>"
+	Kind string // PartitionKeyColumn, ClusteringColumn, RegularColumn or StaticColumn
+
+	// Position is that of the column in the partition key or the clustering
+	// columns, and Descending is set for clustering columns in descending
+	// order.
+	Position   int
+	Descending bool
+}
+
+// Column returns the column of the table by its name.
+func (t TableMetadata) Column(name string) (ColumnMetadata, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return ColumnMetadata{}, false
+}
+
+// releaseVersion returns the major version of the Cassandra release of a
+// session, by which its schema tables are chosen.
+func releaseVersion(session Session) (int, error) {
+	var version string
+	iter := session.Iter(Statement{Stmt: `SELECT release_version FROM system.local WHERE key = 'local'`})
+	iter.Scan(&version)
+	if err := iter.Close(); err != nil {
+		return 0, err
+	}
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("Unable to parse release version %q", version)
+	}
+	return major, nil
+}
+
+// tablesMetadata reads the tables of a keyspace from the schema tables of
+// Cassandra 3.0 and later.
+func tablesMetadata(session Session, keyspace string) ([]TableMetadata, error) {
+	var tables []TableMetadata
+	var table TableMetadata
+	iter := session.Iter(Statement{Stmt: `SELECT table_name, comment, default_time_to_live FROM system_schema.tables WHERE keyspace_name = ?`, Values: []interface{}{keyspace}})
+	for iter.Scan(&table.Name, &table.Comment, &table.DefaultTimeToLive) {
+		table.Keyspace = keyspace
+		tables = append(tables, table)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	var tableName, order string
+	var column ColumnMetadata
+	iter = session.Iter(Statement{Stmt: `SELECT table_name, column_name, kind, position, clustering_order, type FROM system_schema.columns WHERE keyspace_name = ?`, Values: []interface{}{keyspace}})
+	for iter.Scan(&tableName, &column.Name, &column.Kind, &column.Position, &order, &column.Type) {
+		if column.Kind == RegularColumn || column.Kind == StaticColumn {
+			column.Position = 0
+		}
+		column.Descending = order == "desc"
+		addColumnMetadata(tables, tableName, column)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return sortTablesMetadata(tables), nil
+}
+
+// legacyTablesMetadata reads the tables of a keyspace from the schema tables
+// of Cassandra 2.x, which describe column types by their marshal classes.
+func legacyTablesMetadata(session Session, keyspace string) ([]TableMetadata, error) {
+	var tables []TableMetadata
+	var table TableMetadata
+	iter := session.Iter(Statement{Stmt: `SELECT columnfamily_name, comment, default_time_to_live FROM system.schema_columnfamilies WHERE keyspace_name = ?`, Values: []interface{}{keyspace}})
+	for iter.Scan(&table.Name, &table.Comment, &table.DefaultTimeToLive) {
+		table.Keyspace = keyspace
+		tables = append(tables, table)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	var tableName, validator string
+	var column ColumnMetadata
+	iter = session.Iter(Statement{Stmt: `SELECT columnfamily_name, column_name, type, component_index, validator FROM system.schema_columns WHERE keyspace_name = ?`, Values: []interface{}{keyspace}})
+	for iter.Scan(&tableName, &column.Name, &column.Kind, &column.Position, &validator) {
+		switch column.Kind {
+		case "clustering_key":
+			column.Kind = ClusteringColumn
+		case PartitionKeyColumn, StaticColumn:
+		default: // regular and compact_value
+			column.Kind = RegularColumn
+			column.Position = 0
+		}
+		column.Descending = strings.HasPrefix(validator, marshalClassPrefix+"ReversedType(")
+		column.Type = parseClass(validator, 3).String()
+		addColumnMetadata(tables, tableName, column)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return sortTablesMetadata(tables), nil
+}
+
+func addColumnMetadata(tables []TableMetadata, table string, column ColumnMetadata) {
+	for i := range tables {
+		if tables[i].Name != table {
+			continue
+		}
+		switch column.Kind {
+		case PartitionKeyColumn:
+			tables[i].PartitionKey = append(tables[i].PartitionKey, column)
+		case ClusteringColumn:
+			tables[i].ClusteringColumns = append(tables[i].ClusteringColumns, column)
+		}
+		tables[i].Columns = append(tables[i].Columns, column)
+		return
+	}
+}
+
+// sortTablesMetadata orders tables by name and their columns as described by
+// TableMetadata.
+func sortTablesMetadata(tables []TableMetadata) []TableMetadata {
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	for _, table := range tables {
+		sortColumnsMetadata(table.PartitionKey)
+		sortColumnsMetadata(table.ClusteringColumns)
+		sortColumnsMetadata(table.Columns)
+	}
+	return tables
+}
+
+func sortColumnsMetadata(columns []ColumnMetadata) {
+	kindOrder := func(column ColumnMetadata) int {
+		switch column.Kind {
+		case PartitionKeyColumn:
+			return 0
+		case ClusteringColumn:
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		a, b := columns[i], columns[j]
+		switch {
+		case kindOrder(a) != kindOrder(b):
+			return kindOrder(a) < kindOrder(b)
+		case a.Position != b.Position:
+			return a.Position < b.Position
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,71 @@
+package gocqltable
+
+import (
+	"reflect"
+	"testing"
+)
+
+type schemaPost struct {
+	Blog    string
+	Posted  int64
+	Slug    string
+	Title   string
+	Ratings map[string]int
+}
+
+func TestKeyspaceTablesMetadata(t *testing.T) {
+	expected := []TableMetadata{
+		{
+			Keyspace: "blog", Name: "posts", Comment: "Posts by blog", DefaultTimeToLive: 3600,
+			PartitionKey: []ColumnMetadata{
+				{Name: "blog", Type: "varchar", Kind: PartitionKeyColumn},
+			},
+			ClusteringColumns: []ColumnMetadata{
+				{Name: "posted", Type: "bigint", Kind: ClusteringColumn, Descending: true},
+				{Name: "slug", Type: "varchar", Kind: ClusteringColumn, Position: 1},
+			},
+			Columns: []ColumnMetadata{
+				{Name: "blog", Type: "varchar", Kind: PartitionKeyColumn},
+				{Name: "posted", Type: "bigint", Kind: ClusteringColumn, Descending: true},
+				{Name: "slug", Type: "varchar", Kind: ClusteringColumn, Position: 1},
+				{Name: "ratings", Type: "map<varchar, int>", Kind: RegularColumn},
+				{Name: "title", Type: "varchar", Kind: RegularColumn},
+			},
+		},
+	}
+
+	for _, version := range []string{"4.1.3", "3.11.4", "2.1.9"} {
+		s := NewMemorySession()
+		s.releaseVersion = version
+		ks := NewKeyspace("blog")
+		ks.SetBackend(s)
+		if err := ks.CreateWithOptions(KeyspaceOptions{Replication: SimpleStrategy{1}}); err != nil {
+			t.Fatal(err)
+		}
+		if tables, err := ks.TablesMetadata(); err != nil || len(tables) != 0 {
+			t.Errorf("%s: expected no tables but got %v, %v", version, tables, err)
+		}
+		table := ks.NewTable("posts", []string{"Blog"}, []string{"Posted", "Slug"}, schemaPost{})
+		if err := table.CreateWithProperties(`CLUSTERING ORDER BY ("posted" DESC, "slug" ASC)`, `comment = 'Posts by blog'`, `default_time_to_live = 3600`); err != nil {
+			t.Fatal(err)
+		}
+
+		tables, err := ks.TablesMetadata()
+		if err != nil {
+			t.Fatalf("%s: %v", version, err)
+		}
+		if !reflect.DeepEqual(tables, expected) {
+			t.Errorf("%s: expected %+v but got %+v", version, expected, tables)
+		}
+		if column, ok := tables[0].Column("ratings"); !ok || column.Type != "map<varchar, int>" {
+			t.Errorf("%s: expected the ratings column but got %+v", version, column)
+		}
+		names, err := ks.Tables()
+		if err != nil {
+			t.Fatalf("%s: %v", version, err)
+		}
+		if !reflect.DeepEqual(names, []string{"posts"}) {
+			t.Errorf("%s: expected tables %v but got %v", version, []string{"posts"}, names)
+		}
+	}
+}
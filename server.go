@@ -0,0 +1,700 @@
+package gocqltable
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+// Preparer is implemented by sessions that can describe prepared statements,
+// such as MemorySession. A Server needs it to serve prepared statements, which
+// gocql uses for all SELECT, INSERT, UPDATE and DELETE statements.
+type Preparer interface {
+	// Prepare returns the bind markers of a statement and the columns of its
+	// rows.
+	Prepare(stmt string) (binds, columns []gocql.ColumnInfo, err error)
+}
+
+// Server is a local server that speaks the native protocol of Cassandra, in
+// versions 1 to 3, for tests that run real gocql sessions without a cluster.
+// The statements of its clients are run on a Session, usually a
+// MemorySession:
+//
+//	server, err := gocqltable.NewServer(gocqltable.NewMemorySession())
+//	...
+//	defer server.Close()
+//	s, err := server.Cluster().CreateSession()
+//
+// Any Session can be served: Session and Preparer are all a Server needs of
+// what it serves, so fakes and decorators plug in by implementing them. The
+// session must also answer the queries gocql makes of system.local and
+// system.peers when it connects, as MemorySession does.
+//
+// Frames larger than 256 MB, the default limit of Cassandra, are rejected and
+// their connection is closed.
+type Server struct {
+	session  Session
+	listener net.Listener
+
+	mu       sync.Mutex
+	prepared map[string]serverStatement
+	conns    map[net.Conn]bool
+	wg       sync.WaitGroup
+}
+
+// maxFrameSize is the largest frame body a Server reads.
+const maxFrameSize = 256 << 20
+
+type serverStatement struct {
+	stmt           string
+	binds, columns []gocql.ColumnInfo
+}
+
+// NewServer starts a Server for session on a free port of the loopback
+// interface.
+func NewServer(session Session) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to start server: %w", err)
+	}
+	s := &Server{
+		session:  session,
+		listener: listener,
+		prepared: make(map[string]serverStatement),
+		conns:    make(map[net.Conn]bool),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host and port the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Cluster returns the configuration of a gocql cluster of the server, which
+// speaks protocol version 3.
+func (s *Server) Cluster() *gocql.ClusterConfig {
+	cluster := gocql.NewCluster(s.Addr())
+	cluster.ProtoVersion = 3
+	return cluster
+}
+
+// Close stops the server and closes the connections of its clients.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	for {
+		head := make([]byte, 1, 9)
+		if _, err := io.ReadFull(conn, head); err != nil {
+			return
+		}
+		version := head[0] & 0x7F
+		if version > 2 {
+			head = head[:9]
+		} else {
+			head = head[:8]
+		}
+		if _, err := io.ReadFull(conn, head[1:]); err != nil {
+			return
+		}
+		if version < 1 || version > 3 {
+			// Clients retry with the version of the error
+			conn.Write(serverError(3, head, errProtocol, fmt.Sprintf("Invalid or unsupported protocol version: %d", version)))
+			return
+		}
+		flags, op := head[1], head[len(head)-5]
+		length := binary.BigEndian.Uint32(head[len(head)-4:])
+		if length > maxFrameSize {
+			conn.Write(serverError(version, head, errProtocol, fmt.Sprintf("Request is too big: length %d exceeds maximum allowed length %d", length, maxFrameSize)))
+			return
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+
+		var resp []byte
+		if flags&0x01 != 0 {
+			resp = serverError(version, head, errProtocol, "Compressed frames are not supported")
+		} else {
+			resp = s.handle(version, head, op, body)
+		}
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+// Opcodes of the native protocol.
+const (
+	opError     = 0x00
+	opStartup   = 0x01
+	opReady     = 0x02
+	opOptions   = 0x05
+	opSupported = 0x06
+	opQuery     = 0x07
+	opResult    = 0x08
+	opPrepare   = 0x09
+	opExecute   = 0x0A
+	opRegister  = 0x0B
+	opBatch     = 0x0D
+)
+
+// Error codes of the native protocol.
+const (
+	errProtocol   = 0x000A
+	errInvalid    = 0x2200
+	errUnprepared = 0x2500
+)
+
+// Result kinds of the native protocol.
+const (
+	resultVoid     = 0x0001
+	resultRows     = 0x0002
+	resultKeyspace = 0x0003
+	resultPrepared = 0x0004
+)
+
+// handle returns the response frame to a request frame.
+func (s *Server) handle(version byte, head []byte, op byte, body []byte) []byte {
+	r := &frameReader{data: body}
+	w := newFrameWriter(version, head)
+	switch op {
+	case opOptions:
+		w.op = opSupported
+		w.short(2)
+		w.string("CQL_VERSION")
+		w.stringList([]string{"3.0.0"})
+		w.string("COMPRESSION")
+		w.stringList(nil)
+	case opStartup:
+		if options := r.stringMap(); options["COMPRESSION"] != "" {
+			return serverError(version, head, errProtocol, "Compression is not supported")
+		}
+		w.op = opReady
+	case opRegister:
+		w.op = opReady
+	case opQuery:
+		stmt := Statement{Stmt: r.longString()}
+		values := r.queryParams(version, &stmt)
+		if r.err != nil {
+			return serverError(version, head, errProtocol, r.err.Error())
+		}
+		var prepared serverStatement
+		if len(values) > 0 {
+			var err error
+			if prepared, err = s.prepare(stmt.Stmt); err != nil {
+				return serverError(version, head, errInvalid, err.Error())
+			}
+		}
+		return s.execute(w, prepared, stmt, values)
+	case opPrepare:
+		prepared, err := s.prepare(r.longString())
+		if err != nil {
+			return serverError(version, head, errInvalid, err.Error())
+		}
+		w.int(resultPrepared)
+		w.shortBytes(prepared.id())
+		w.metadata(prepared.binds, nil, version)
+		if version > 1 {
+			w.metadata(prepared.columns, nil, version)
+		}
+	case opExecute:
+		id := r.shortBytes()
+		s.mu.Lock()
+		prepared, ok := s.prepared[string(id)]
+		s.mu.Unlock()
+		if !ok {
+			w.op = opError
+			w.int(errUnprepared)
+			w.string(fmt.Sprintf("Prepared query with ID %x not found", id))
+			w.shortBytes(id)
+			return w.frame()
+		}
+		stmt := Statement{Stmt: prepared.stmt}
+		var values [][]byte
+		if version == 1 {
+			values = r.values(false)
+			c := gocql.Consistency(r.short())
+			stmt.Consistency = &c
+		} else {
+			values = r.queryParams(version, &stmt)
+		}
+		if r.err != nil {
+			return serverError(version, head, errProtocol, r.err.Error())
+		}
+		return s.execute(w, prepared, stmt, values)
+	case opBatch:
+		batch, err := s.batch(r, version)
+		if err == nil {
+			err = s.session.Batch(batch)
+		}
+		if err != nil {
+			return serverError(version, head, errInvalid, err.Error())
+		}
+		w.int(resultVoid)
+	default:
+		return serverError(version, head, errProtocol, fmt.Sprintf("Unsupported opcode 0x%02x", op))
+	}
+	return w.frame()
+}
+
+// prepare returns the prepared statement of stmt.
+func (s *Server) prepare(stmt string) (serverStatement, error) {
+	preparer, ok := s.session.(Preparer)
+	if !ok {
+		return serverStatement{}, fmt.Errorf("Unable to prepare %q: %T does not implement Preparer", stmt, s.session)
+	}
+	binds, columns, err := preparer.Prepare(stmt)
+	if err != nil {
+		return serverStatement{}, err
+	}
+	prepared := serverStatement{stmt: stmt, binds: binds, columns: columns}
+	s.mu.Lock()
+	s.prepared[string(prepared.id())] = prepared
+	s.mu.Unlock()
+	return prepared, nil
+}
+
+func (p serverStatement) id() []byte {
+	id := md5.Sum([]byte(p.stmt))
+	return id[:]
+}
+
+// execute runs a statement with values encoded as its bind markers, and
+// returns the response frame with its result.
+func (s *Server) execute(w *frameWriter, prepared serverStatement, stmt Statement, values [][]byte) []byte {
+	if len(values) != len(prepared.binds) {
+		return serverError(w.version, w.head, errInvalid, fmt.Sprintf("There were %d markers(?) in CQL but %d bound variables", len(prepared.binds), len(values)))
+	}
+	for i, data := range values {
+		value, err := decodeValue(prepared.binds[i].TypeInfo, w.version, data)
+		if err != nil {
+			return serverError(w.version, w.head, errInvalid, err.Error())
+		}
+		stmt.Values = append(stmt.Values, value)
+	}
+
+	iter := bufferIter(s.session.Iter(stmt))
+	if iter.err != nil {
+		return serverError(w.version, w.head, errInvalid, iter.err.Error())
+	}
+	columns := iter.columns
+	for i, column := range columns {
+		info, ok := protoType(column.TypeInfo, w.version)
+		if !ok {
+			continue
+		}
+		for _, row := range iter.rows {
+			value, err := decodeValue(column.TypeInfo, 3, row[i])
+			if err == nil {
+				row[i], err = marshal(info, value)
+			}
+			if err != nil {
+				return serverError(w.version, w.head, errInvalid, err.Error())
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		if parsed, _, err := parseCQL(stmt.Stmt); err == nil {
+			if use, ok := parsed.(useStmt); ok {
+				w.int(resultKeyspace)
+				w.string(use.keyspace)
+				return w.frame()
+			}
+		}
+		w.int(resultVoid)
+		return w.frame()
+	}
+	w.int(resultRows)
+	w.metadata(columns, iter.pageState, w.version)
+	w.int(len(iter.rows))
+	for _, row := range iter.rows {
+		for _, data := range row {
+			w.bytes(data)
+		}
+	}
+	return w.frame()
+}
+
+// batch reads the body of a BATCH frame.
+func (s *Server) batch(r *frameReader, version byte) (Batch, error) {
+	if version < 2 {
+		return Batch{}, errors.New("Batches are not supported by protocol version 1")
+	}
+	batch := Batch{Type: gocql.BatchType(r.byte())}
+	n := int(r.short())
+	stmts := make([]string, n)
+	values := make([][][]byte, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		if kind := r.byte(); kind == 0 {
+			stmts[i] = r.longString()
+		} else {
+			id := r.shortBytes()
+			s.mu.Lock()
+			prepared, ok := s.prepared[string(id)]
+			s.mu.Unlock()
+			if !ok {
+				return Batch{}, fmt.Errorf("Prepared query with ID %x not found", id)
+			}
+			stmts[i] = prepared.stmt
+		}
+		values[i] = r.values(false)
+	}
+	c := gocql.Consistency(r.short())
+	batch.Consistency = &c
+	if version > 2 {
+		if flags := r.byte(); flags&0x40 != 0 {
+			return Batch{}, errors.New("Named values are not supported")
+		}
+	}
+	if r.err != nil {
+		return Batch{}, r.err
+	}
+
+	for i, stmt := range stmts {
+		statement := Statement{Stmt: stmt}
+		if len(values[i]) > 0 {
+			prepared, err := s.prepare(stmt)
+			if err != nil {
+				return Batch{}, err
+			}
+			if len(values[i]) != len(prepared.binds) {
+				return Batch{}, fmt.Errorf("There were %d markers(?) in CQL but %d bound variables", len(prepared.binds), len(values[i]))
+			}
+			for j, data := range values[i] {
+				value, err := decodeValue(prepared.binds[j].TypeInfo, version, data)
+				if err != nil {
+					return Batch{}, err
+				}
+				statement.Values = append(statement.Values, value)
+			}
+		}
+		batch.Statements = append(batch.Statements, statement)
+	}
+	return batch, nil
+}
+
+// protoType returns the type of a column as it is encoded by a protocol
+// version if that differs from how it is stored, which is for collections
+// before version 3: they are encoded with 16 bit lengths.
+func protoType(info gocql.TypeInfo, version byte) (gocql.TypeInfo, bool) {
+	t, ok := resolveType(info).(typeInfo)
+	if !ok || version > 2 {
+		return info, false
+	}
+	switch t.typ {
+	case gocql.TypeList, gocql.TypeSet, gocql.TypeMap:
+		t.proto = version
+		return t, true
+	}
+	return info, false
+}
+
+// decodeValue decodes a value encoded by a protocol version into the Go type
+// its column is decoded into by default.
+func decodeValue(info gocql.TypeInfo, version byte, data []byte) (interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	info, _ = protoType(info, version)
+	v := newValue(info)
+	if err := unmarshal(info, data, v); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(v).Elem().Interface(), nil
+}
+
+// serverError returns an error frame.
+func serverError(version byte, head []byte, code int, message string) []byte {
+	w := newFrameWriter(version, head)
+	w.op = opError
+	w.int(code)
+	w.string(message)
+	return w.frame()
+}
+
+// frameReader reads the body of a request frame.
+type frameReader struct {
+	data []byte
+	err  error
+}
+
+func (r *frameReader) read(n int) []byte {
+	if r.err != nil || n > len(r.data) {
+		r.err = errors.New("Unexpected end of frame")
+		return make([]byte, n)
+	}
+	p := r.data[:n]
+	r.data = r.data[n:]
+	return p
+}
+
+func (r *frameReader) byte() byte {
+	return r.read(1)[0]
+}
+
+func (r *frameReader) short() uint16 {
+	return binary.BigEndian.Uint16(r.read(2))
+}
+
+func (r *frameReader) int() int32 {
+	return int32(binary.BigEndian.Uint32(r.read(4)))
+}
+
+func (r *frameReader) string() string {
+	return string(r.read(int(r.short())))
+}
+
+func (r *frameReader) longString() string {
+	n := r.int()
+	if n < 0 {
+		r.err = errors.New("Invalid string length")
+		return ""
+	}
+	return string(r.read(int(n)))
+}
+
+func (r *frameReader) shortBytes() []byte {
+	return r.read(int(r.short()))
+}
+
+// bytesValue reads a [bytes], which is nil for null.
+func (r *frameReader) bytesValue() []byte {
+	n := r.int()
+	if n < 0 {
+		return nil
+	}
+	return r.read(int(n))
+}
+
+func (r *frameReader) stringMap() map[string]string {
+	m := make(map[string]string)
+	for n := int(r.short()); n > 0 && r.err == nil; n-- {
+		key := r.string()
+		m[key] = r.string()
+	}
+	return m
+}
+
+// values reads the bound values of a statement.
+func (r *frameReader) values(named bool) [][]byte {
+	var values [][]byte
+	for n := int(r.short()); n > 0 && r.err == nil; n-- {
+		if named {
+			r.string()
+		}
+		values = append(values, r.bytesValue())
+	}
+	return values
+}
+
+// queryParams reads the parameters of QUERY and EXECUTE frames into stmt and
+// returns the bound values.
+func (r *frameReader) queryParams(version byte, stmt *Statement) [][]byte {
+	c := gocql.Consistency(r.short())
+	stmt.Consistency = &c
+	if version == 1 {
+		return nil
+	}
+	flags := r.byte()
+	var values [][]byte
+	if flags&0x01 != 0 {
+		values = r.values(flags&0x40 != 0)
+	}
+	if flags&0x04 != 0 {
+		stmt.PageSize = int(r.int())
+		stmt.Paged = true
+	}
+	if flags&0x08 != 0 {
+		stmt.PageState = r.bytesValue()
+	}
+	if flags&0x10 != 0 {
+		c := gocql.SerialConsistency(r.short())
+		stmt.SerialConsistency = &c
+	}
+	if flags&0x20 != 0 {
+		r.read(8) // default timestamp
+	}
+	return values
+}
+
+// frameWriter writes a response frame.
+type frameWriter struct {
+	version byte
+	head    []byte // of the request
+	op      byte
+	body    []byte
+}
+
+func newFrameWriter(version byte, head []byte) *frameWriter {
+	return &frameWriter{version: version, head: head, op: opResult}
+}
+
+// frame returns the frame with its header.
+func (w *frameWriter) frame() []byte {
+	frame := []byte{0x80 | w.version, 0}
+	if w.version > 2 {
+		frame = append(frame, w.head[2], w.head[3])
+	} else {
+		frame = append(frame, w.head[2])
+	}
+	frame = append(frame, w.op)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(w.body)))
+	return append(frame, w.body...)
+}
+
+func (w *frameWriter) short(n int) {
+	w.body = binary.BigEndian.AppendUint16(w.body, uint16(n))
+}
+
+func (w *frameWriter) int(n int) {
+	w.body = binary.BigEndian.AppendUint32(w.body, uint32(n))
+}
+
+func (w *frameWriter) string(s string) {
+	w.short(len(s))
+	w.body = append(w.body, s...)
+}
+
+func (w *frameWriter) stringList(l []string) {
+	w.short(len(l))
+	for _, s := range l {
+		w.string(s)
+	}
+}
+
+func (w *frameWriter) shortBytes(p []byte) {
+	w.short(len(p))
+	w.body = append(w.body, p...)
+}
+
+// bytes writes a [bytes], which is null for nil.
+func (w *frameWriter) bytes(p []byte) {
+	w.body = appendBytes(w.body, p)
+}
+
+// metadata writes the metadata of columns, with the page state of the next
+// page if there is one.
+func (w *frameWriter) metadata(columns []gocql.ColumnInfo, pageState []byte, version byte) {
+	var flags int
+	global := len(columns) > 0
+	for _, column := range columns {
+		global = global && column.Keyspace == columns[0].Keyspace && column.Table == columns[0].Table
+	}
+	if global {
+		flags |= 0x01
+	}
+	if len(pageState) > 0 {
+		flags |= 0x02
+	}
+	w.int(flags)
+	w.int(len(columns))
+	if len(pageState) > 0 {
+		w.bytes(pageState)
+	}
+	if global {
+		w.string(columns[0].Keyspace)
+		w.string(columns[0].Table)
+	}
+	for _, column := range columns {
+		if !global {
+			w.string(column.Keyspace)
+			w.string(column.Table)
+		}
+		w.string(column.Name)
+		w.option(column.TypeInfo, version)
+	}
+}
+
+// option writes the type of a column. Types that have no option in the
+// protocol version are written as custom types named by their marshal class,
+// like Cassandra does.
+func (w *frameWriter) option(info gocql.TypeInfo, version byte) {
+	info = resolveType(info)
+	t, _ := info.(typeInfo)
+	switch typ := info.Type(); typ {
+	case gocql.TypeAscii, gocql.TypeBigInt, gocql.TypeBlob, gocql.TypeBoolean,
+		gocql.TypeCounter, gocql.TypeDecimal, gocql.TypeDouble, gocql.TypeFloat,
+		gocql.TypeInt, gocql.TypeTimestamp, gocql.TypeUUID, gocql.TypeVarchar,
+		gocql.TypeVarint, gocql.TypeTimeUUID, gocql.TypeInet:
+		w.short(int(typ))
+		return
+	case gocql.TypeText:
+		w.short(int(gocql.TypeVarchar))
+		return
+	case gocql.TypeList, gocql.TypeSet:
+		w.short(int(typ))
+		w.option(t.elem, version)
+		return
+	case gocql.TypeMap:
+		w.short(int(typ))
+		w.option(t.key, version)
+		w.option(t.elem, version)
+		return
+	case gocql.TypeTuple:
+		if version > 2 {
+			w.short(int(typ))
+			w.short(len(t.elems))
+			for _, elem := range t.elems {
+				w.option(elem, version)
+			}
+			return
+		}
+	case gocql.TypeUDT:
+		if version > 2 {
+			w.short(int(typ))
+			w.string(t.keyspace)
+			w.string(t.name)
+			w.short(len(t.fields))
+			for i, field := range t.fields {
+				w.string(field)
+				w.option(t.elems[i], version)
+			}
+			return
+		}
+	}
+	w.short(int(gocql.TypeCustom))
+	w.string(marshalClass(info))
+}
@@ -0,0 +1,145 @@
+package gocqltable
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+type serverOrder struct {
+	Customer string
+	Number   int
+	Quantity int16
+	Placed   time.Time
+	Items    []string
+	Prices   map[string]float64
+	Shipped  Date
+	Address  serverAddress
+}
+
+type serverAddress struct {
+	Street string
+	Zip    int
+}
+
+func TestServer(t *testing.T) {
+	// gocql caches prepared statements by address, so each protocol version
+	// gets its own server
+	for _, proto := range []int{2, 3} {
+		server, err := NewServer(NewMemorySession())
+		if err != nil {
+			t.Fatal(err)
+		}
+		cluster := server.Cluster()
+		cluster.ProtoVersion = proto
+		cluster.Timeout = 5 * time.Second
+		s, err := cluster.CreateSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		testServerSession(t, NewSession(s), proto)
+		s.Close()
+		server.Close()
+	}
+}
+
+func testServerSession(t *testing.T, session Session, proto int) {
+	ks := NewKeyspace("shop")
+	ks.SetBackend(session)
+	if err := ks.Create(map[string]interface{}{"class": "SimpleStrategy", "replication_factor": 1}, true); err != nil {
+		t.Fatalf("protocol %d: %v", proto, err)
+	}
+	defer ks.Drop()
+	if err := ks.CreateType("address", serverAddress{}); err != nil {
+		t.Fatalf("protocol %d: %v", proto, err)
+	}
+	table := ks.NewTable("orders", []string{"Customer"}, []string{"Number"}, serverOrder{})
+	if err := table.Create(); err != nil {
+		t.Fatalf("protocol %d: %v", proto, err)
+	}
+
+	placed := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	var orders []serverOrder
+	batch := Batch{Type: gocql.LoggedBatch}
+	for i := 1; i <= 5; i++ {
+		order := serverOrder{"ann", i, int16(i * 10), placed, []string{"book"}, map[string]float64{"book": 9.5}, NewDate(placed.AddDate(0, 0, i)), serverAddress{"Main St", 1000 + i}}
+		orders = append(orders, order)
+		batch.Statements = append(batch.Statements, Statement{
+			Stmt:   `INSERT INTO "shop"."orders" ("customer", "number", "quantity", "placed", "items", "prices", "shipped", "address") VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			Values: []interface{}{order.Customer, order.Number, order.Quantity, order.Placed, order.Items, order.Prices, order.Shipped, order.Address},
+		})
+	}
+	if err := session.Batch(batch); err != nil {
+		t.Fatalf("protocol %d: %v", proto, err)
+	}
+
+	row, err := table.Query(`SELECT * FROM "shop"."orders" WHERE "customer" = ? AND "number" = ?`, "ann", 3).FetchRow()
+	if err != nil {
+		t.Fatalf("protocol %d: %v", proto, err)
+	}
+	if order := *row.(*serverOrder); !reflect.DeepEqual(order, orders[2]) {
+		t.Errorf("protocol %d: expected %v but got %v", proto, orders[2], order)
+	}
+
+	var numbers []int
+	var state []byte
+	for pages := 0; pages == 0 || state != nil; pages++ {
+		if pages > 3 {
+			t.Fatalf("protocol %d: expected 3 pages", proto)
+		}
+		iter := table.Query(`SELECT * FROM "shop"."orders" WHERE "customer" = ?`, "ann").PageSize(2).PageState(state).Fetch()
+		for row, err := range iter.All() {
+			if err != nil {
+				t.Fatalf("protocol %d: %v", proto, err)
+			}
+			numbers = append(numbers, row.(*serverOrder).Number)
+		}
+		state = iter.PageState()
+	}
+	if expected := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(numbers, expected) {
+		t.Errorf("protocol %d: expected %v but got %v", proto, expected, numbers)
+	}
+
+	if err := table.Query(`SELECT * FROM "shop"."orders" WHERE "quantity" = ?`, int16(10)).Fetch().Close(); err == nil {
+		t.Errorf("protocol %d: expected the errors of the session to be returned", proto)
+	}
+
+	tables, err := ks.Tables()
+	if err != nil {
+		t.Fatalf("protocol %d: %v", proto, err)
+	}
+	if !reflect.DeepEqual(tables, []string{"orders"}) {
+		t.Errorf("protocol %d: expected tables %v but got %v", proto, []string{"orders"}, tables)
+	}
+}
+
+func TestServerFrameTooBig(t *testing.T) {
+	server, err := NewServer(NewMemorySession())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	conn, err := net.Dial("tcp", server.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	head := []byte{0x03, 0x00, 0x00, 0x01, opStartup, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(head[5:], maxFrameSize+1)
+	if _, err := conn.Write(head); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) < 9 || resp[4] != opError {
+		t.Errorf("expected an error frame but got %x", resp)
+	}
+}
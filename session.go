@@ -0,0 +1,141 @@
+package gocqltable
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gocql/gocql"
+)
+
+// Session runs the statements of keyspaces, tables and queries. Those of a
+// gocql session run through NewSession; other implementations can stand in
+// for it in tests, or decorate it, see SetDefaultBackend and
+// Keyspace.SetBackend.
+type Session interface {
+	Exec(stmt Statement) error
+	Iter(stmt Statement) Iter
+	Batch(batch Batch) error
+}
+
+// Statement is a CQL statement with its values and the options it is run
+// with.
+type Statement struct {
+	Stmt   string
+	Values []interface{}
+
+	// Consistency and SerialConsistency are those of the session if nil.
+	Consistency       *gocql.Consistency
+	SerialConsistency *gocql.SerialConsistency
+
+	// PageSize is that of the session if 0. If Paged is set only the page
+	// that starts at PageState is fetched, see Query.PageState.
+	PageSize  int
+	PageState []byte
+	Paged     bool
+
+	// Context is that of the statement: the session gives up on the
+	// statement, and fails with the error of the context, when it is done.
+	// It is context.Background() if nil.
+	Context context.Context
+}
+
+// Batch is a batch of statements, which are run at the consistency of the
+// batch. The statements can not have options of their own: consistencies,
+// paging or contexts.
+type Batch struct {
+	Type        gocql.BatchType
+	Statements  []Statement
+	Consistency *gocql.Consistency
+}
+
+// validate returns an error if a statement of the batch has options of its
+// own, which batches can not run statements with.
+func (b Batch) validate() error {
+	for _, stmt := range b.Statements {
+		if stmt.Consistency != nil || stmt.SerialConsistency != nil || stmt.PageSize != 0 || stmt.Paged || stmt.Context != nil {
+			return fmt.Errorf("Unable to run %q in a batch as it has options of its own", stmt.Stmt)
+		}
+	}
+	return nil
+}
+
+// Iter is an iterator of the rows of a statement, like gocql.Iter.
+type Iter interface {
+	// Columns returns the columns of the rows.
+	Columns() []gocql.ColumnInfo
+	// Scan scans the next row into dest like gocql.Iter.Scan, returning
+	// false after the last row or when it fails.
+	Scan(dest ...interface{}) bool
+	// PageState returns the page state of the page after the current one,
+	// which is empty if it is the last page.
+	PageState() []byte
+	Close() error
+}
+
+// NewSession returns the Session of a gocql session.
+func NewSession(s *gocql.Session) Session {
+	return gocqlSession{s}
+}
+
+// gocqlSessionOf returns the gocql session of s, or nil if s is not that of a
+// gocql session.
+func gocqlSessionOf(s Session) *gocql.Session {
+	if s, ok := s.(gocqlSession); ok {
+		return s.session
+	}
+	return nil
+}
+
+// newBackend returns the Session of a gocql session, or nil if s is nil.
+func newBackend(s *gocql.Session) Session {
+	if s == nil {
+		return nil
+	}
+	return NewSession(s)
+}
+
+type gocqlSession struct {
+	session *gocql.Session
+}
+
+func (s gocqlSession) Exec(stmt Statement) error {
+	return s.query(stmt).Exec()
+}
+
+func (s gocqlSession) Iter(stmt Statement) Iter {
+	return s.query(stmt).Iter()
+}
+
+func (s gocqlSession) Batch(batch Batch) error {
+	if err := batch.validate(); err != nil {
+		return err
+	}
+	b := s.session.NewBatch(batch.Type)
+	if batch.Consistency != nil {
+		b.Cons = *batch.Consistency
+	}
+	for _, stmt := range batch.Statements {
+		b.Query(stmt.Stmt, bindValues(stmt.Values)...)
+	}
+	return s.session.ExecuteBatch(b)
+}
+
+func (s gocqlSession) query(stmt Statement) *gocql.Query {
+	query := s.session.Query(stmt.Stmt, bindValues(stmt.Values)...)
+	if stmt.PageSize > 0 {
+		query.PageSize(stmt.PageSize)
+	}
+	if stmt.Paged {
+		query.PageState(stmt.PageState)
+	}
+	if stmt.Consistency != nil {
+		query.Consistency(*stmt.Consistency)
+	}
+	if stmt.SerialConsistency != nil {
+		query.SerialConsistency(*stmt.SerialConsistency)
+	}
+	if stmt.Context != nil {
+		query = query.WithContext(stmt.Context)
+	}
+	return query
+}
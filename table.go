@@ -5,8 +5,6 @@ import (
 	"strings"
 
 	"github.com/gocql/gocql"
-
-	r "github.com/kristoiv/gocqltable/reflect"
 )
 
 type TableInterface interface {
@@ -20,6 +18,12 @@ type TableInterface interface {
 	Row() interface{}
 }
 
+// NamingTable is implemented by tables that name their columns by a naming
+// strategy, as Table does. See TableNamingStrategy.
+type NamingTable interface {
+	NamingStrategy() NamingStrategy
+}
+
 type Table struct {
 	name      string
 	rowKeys   []string
@@ -27,7 +31,11 @@ type Table struct {
 	row       interface{}
 
 	keyspace Keyspace
-	session  *gocql.Session
+	session  Session
+	naming   NamingStrategy
+
+	consistency       *gocql.Consistency
+	serialConsistency *gocql.SerialConsistency
 }
 
 func (t Table) Create() error {
@@ -44,8 +52,8 @@ func (t Table) create(props ...string) error {
 		t.session = defaultSession
 	}
 
-	rowKeys := t.RowKeys()
-	rangeKeys := t.RangeKeys()
+	rowKeys := t.columnNames(t.RowKeys())
+	rangeKeys := t.columnNames(t.RangeKeys())
 
 	pkString := "PRIMARY KEY ((" + strings.Join(rowKeys, ", ") + ")"
 	if len(rangeKeys) > 0 {
@@ -53,20 +61,9 @@ func (t Table) create(props ...string) error {
 	}
 	pkString = pkString + ")"
 
-	fields := []string{}
-
-	m, ok := r.StructToMap(t.Row())
-	if !ok {
-		panic("Unable to get map from struct during create table")
-	}
-
-	for key, value := range m {
-		key = strings.ToLower(key)
-		typ, err := stringTypeOf(value)
-		if err != nil {
-			return err
-		}
-		fields = append(fields, fmt.Sprintf(`%q %v`, key, typ))
+	fields, err := columnDefinitions(t.Row(), t.keyspace.Name(), false, t.NamingStrategy())
+	if err != nil {
+		return err
 	}
 
 	// Add primary key value to fields list
@@ -77,7 +74,7 @@ func (t Table) create(props ...string) error {
 		propertiesString = "WITH " + strings.Join(props, " AND ")
 	}
 
-	return t.session.Query(fmt.Sprintf(`CREATE TABLE %q.%q (%s) %s`, t.Keyspace().Name(), t.Name(), strings.Join(fields, ", "), propertiesString)).Exec()
+	return t.session.Exec(Statement{Stmt: fmt.Sprintf(`CREATE TABLE %q.%q (%s) %s`, t.Keyspace().Name(), t.Name(), strings.Join(fields, ", "), propertiesString)})
 
 }
 
@@ -85,7 +82,7 @@ func (t Table) Drop() error {
 	if t.session == nil {
 		t.session = defaultSession
 	}
-	return t.session.Query(fmt.Sprintf(`DROP TABLE %q.%q`, t.Keyspace().Name(), t.Name())).Exec()
+	return t.session.Exec(Statement{Stmt: fmt.Sprintf(`DROP TABLE %q.%q`, t.Keyspace().Name(), t.Name())})
 }
 
 func (t Table) Query(statement string, values ...interface{}) Query {
@@ -97,7 +94,11 @@ func (t Table) Query(statement string, values ...interface{}) Query {
 		Values:    values,
 
 		Table:   t,
-		Session: t.session,
+		Session: gocqlSessionOf(t.session),
+		Backend: t.session,
+
+		consistency:       t.consistency,
+		serialConsistency: t.serialConsistency,
 	}
 }
 
@@ -120,3 +121,43 @@ func (t Table) RangeKeys() []string {
 func (t Table) Row() interface{} {
 	return t.row
 }
+
+// ValidateRow returns an error if row is not a struct that can be stored as
+// the rows of a table: it must be a struct, or a pointer to one, that has
+// unique column keys and fields of types that can be stored. The errors wrap
+// reflect.ErrNotAStruct and reflect.ErrDuplicateColumn where they apply.
+func ValidateRow(row interface{}) error {
+	_, err := columnDefinitions(row, "", false, LowerCase)
+	return err
+}
+
+// NamingStrategy returns the naming strategy of the table, which defaults to
+// that of its keyspace.
+func (t Table) NamingStrategy() NamingStrategy {
+	return namingOrDefault(t.naming)
+}
+
+func (t *Table) SetNamingStrategy(naming NamingStrategy) {
+	t.naming = naming
+}
+
+// SetConsistency sets the default consistency of the queries of the table,
+// which is that of its keyspace if it is not set.
+func (t *Table) SetConsistency(c gocql.Consistency) {
+	t.consistency = &c
+}
+
+// SetSerialConsistency sets the default serial consistency of the queries of
+// the table, which is that of its keyspace if it is not set.
+func (t *Table) SetSerialConsistency(c gocql.SerialConsistency) {
+	t.serialConsistency = &c
+}
+
+// columnNames returns the quoted column names of keys.
+func (t Table) columnNames(keys []string) []string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = fmt.Sprintf("%q", t.NamingStrategy().ColumnName(key))
+	}
+	return names
+}
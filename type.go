@@ -3,52 +3,284 @@ package gocqltable
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+
+	r "github.com/kristoiv/gocqltable/reflect"
 )
 
 type Counter int
 
+// Ascii and Text are strings stored in ascii and text columns. A plain string
+// is stored as varchar.
+type Ascii string
+type Text string
+
+// TimeUUID is a gocql.UUID stored in a timeuuid column.
+type TimeUUID gocql.UUID
+
+func (u TimeUUID) String() string {
+	return gocql.UUID(u).String()
+}
+
+func (u TimeUUID) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	return marshal(info, gocql.UUID(u))
+}
+
+func (u *TimeUUID) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	return unmarshal(info, data, (*gocql.UUID)(u))
+}
+
+// Date is a day stored in a date column. Only the year, month and day of the
+// UTC time are kept.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the Date of t.
+func NewDate(t time.Time) Date {
+	y, m, d := t.UTC().Date()
+	return Date{time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	return marshal(info, d.Time)
+}
+
+func (d *Date) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	return unmarshal(info, data, &d.Time)
+}
+
+// TimeOfDay is the time since midnight stored in a time column.
+type TimeOfDay time.Duration
+
+func (t TimeOfDay) String() string {
+	return time.Duration(t).String()
+}
+
+func (t TimeOfDay) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	return marshal(info, time.Duration(t))
+}
+
+func (t *TimeOfDay) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	return unmarshal(info, data, (*time.Duration)(t))
+}
+
+const marshalClassPrefix = "org.apache.cassandra.db.marshal."
+
+// columnType returns the CQL type of a column as described by the server.
+func columnType(info gocql.TypeInfo) gocql.Type {
+	return resolveType(info).Type()
+}
+
 func stringTypeOf(i interface{}) (string, error) {
-	_, isByteSlice := i.([]byte)
-	if !isByteSlice {
-		// Check if we found a higher kinded type
-		switch reflect.ValueOf(i).Kind() {
-		case reflect.Slice:
-			elemVal := reflect.Indirect(reflect.New(reflect.TypeOf(i).Elem())).Interface()
-			ct := cassaType(elemVal)
-			if ct == gocql.TypeCustom {
-				return "", fmt.Errorf("Unsupported type %T", i)
-			}
-			return fmt.Sprintf("list<%v>", ct), nil
-		case reflect.Map:
-			keyVal := reflect.Indirect(reflect.New(reflect.TypeOf(i).Key())).Interface()
-			elemVal := reflect.Indirect(reflect.New(reflect.TypeOf(i).Elem())).Interface()
-			keyCt := cassaType(keyVal)
-			elemCt := cassaType(elemVal)
-			if keyCt == gocql.TypeCustom || elemCt == gocql.TypeCustom {
-				return "", fmt.Errorf("Unsupported map key or value type %T", i)
+	return typeOf(reflect.TypeOf(i), "", false, false)
+}
+
+// typeOf returns the CQL type of values of type t in a keyspace. Collections
+// nested in other collections, tuples or user defined types are frozen.
+// Structs are stored as tuples rather than user defined types if asTuple is
+// set.
+func typeOf(t reflect.Type, keyspace string, nested, asTuple bool) (string, error) {
+	if t == nil {
+		return "", errors.New("Unsupported type <nil>")
+	}
+	if rt, ok := lookupType(t); ok {
+		return rt.cqlType, nil
+	}
+	if ct := cassaType(reflect.Zero(t).Interface()); ct != gocql.TypeCustom {
+		return cassaTypeToString(ct)
+	}
+
+	var typ string
+	switch t.Kind() {
+	case reflect.Ptr:
+		// Pointers are nullable columns of their element type
+		return typeOf(t.Elem(), keyspace, nested, asTuple)
+	case reflect.Slice:
+		elemType, err := typeOf(t.Elem(), keyspace, true, asTuple)
+		if err != nil {
+			return "", err
+		}
+		typ = fmt.Sprintf("list<%v>", elemType)
+	case reflect.Map:
+		keyType, err := typeOf(t.Key(), keyspace, true, asTuple)
+		if err != nil {
+			return "", err
+		}
+		// Maps with empty struct values, such as map[string]struct{}, are sets
+		if isSet(t) {
+			typ = fmt.Sprintf("set<%v>", keyType)
+			break
+		}
+		elemType, err := typeOf(t.Elem(), keyspace, true, asTuple)
+		if err != nil {
+			return "", err
+		}
+		typ = fmt.Sprintf("map<%v, %v>", keyType, elemType)
+	case reflect.Array:
+		// Arrays are tuples of their element type
+		if t.Len() == 0 {
+			return "", fmt.Errorf("Unsupported empty tuple type %v", t)
+		}
+		elemType, err := typeOf(t.Elem(), keyspace, true, asTuple)
+		if err != nil {
+			return "", err
+		}
+		elemTypes := make([]string, t.Len())
+		for i := range elemTypes {
+			elemTypes[i] = elemType
+		}
+		return fmt.Sprintf("frozen<tuple<%s>>", strings.Join(elemTypes, ", ")), nil
+	case reflect.Struct:
+		if asTuple {
+			return tupleTypeOf(t, keyspace)
+		}
+		// Structs are stored as user defined types
+		name, err := udtName(keyspace, t)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("frozen<%q>", name), nil
+	default:
+		return "", fmt.Errorf("Unsupported type %v", t)
+	}
+	if nested {
+		return fmt.Sprintf("frozen<%s>", typ), nil
+	}
+	return typ, nil
+}
+
+// tupleTypeOf returns the tuple type of a struct, with an element per
+// exported field in struct order.
+func tupleTypeOf(t reflect.Type, keyspace string) (string, error) {
+	infos, ok := r.Fields(reflect.Zero(t).Interface())
+	if !ok {
+		return "", r.Validate(reflect.Zero(t).Interface())
+	}
+	elemTypes := []string{}
+	for _, info := range infos {
+		field := t.FieldByIndex(info.Index)
+		if field.PkgPath != "" {
+			continue
+		}
+		elemType := info.Type
+		if elemType == "" {
+			var err error
+			if elemType, err = typeOf(field.Type, keyspace, true, true); err != nil {
+				return "", err
 			}
-			return fmt.Sprintf("map<%v, %v>", keyCt, elemCt), nil
 		}
+		elemTypes = append(elemTypes, elemType)
 	}
-	ct := cassaType(i)
-	if ct == gocql.TypeCustom {
-		return "", fmt.Errorf("Unsupported type %T", i)
+	if len(elemTypes) == 0 {
+		return "", fmt.Errorf("Unsupported empty tuple type %v", t)
+	}
+	return fmt.Sprintf("frozen<tuple<%s>>", strings.Join(elemTypes, ", ")), nil
+}
+
+// udt describes the user defined type a struct type was created as in a
+// keyspace by Keyspace.CreateType.
+type udt struct {
+	name   string
+	naming NamingStrategy
+}
+
+type udtKey struct {
+	keyspace string
+	t        reflect.Type
+}
+
+var udtsMutex sync.RWMutex
+var udts = make(map[udtKey]udt)
+
+// udtName returns the name of the user defined type a struct is stored as in
+// a keyspace: the name it was created with there by Keyspace.CreateType, or
+// else its lower cased type name.
+func udtName(keyspace string, t reflect.Type) (string, error) {
+	udtsMutex.RLock()
+	u, found := udts[udtKey{keyspace, t}]
+	udtsMutex.RUnlock()
+	if found {
+		return u.name, nil
+	}
+	if t.Name() == "" {
+		return "", fmt.Errorf("Unsupported anonymous struct type %v", t)
+	}
+	return strings.ToLower(t.Name()), nil
+}
+
+// udtNaming returns the naming strategy the fields of a struct type are stored
+// with in the user defined types of a keyspace.
+func udtNaming(keyspace string, t reflect.Type) NamingStrategy {
+	udtsMutex.RLock()
+	u := udts[udtKey{keyspace, t}]
+	udtsMutex.RUnlock()
+	return namingOrDefault(u.naming)
+}
+
+func registerUDT(keyspace string, t reflect.Type, name string, naming NamingStrategy) {
+	udtsMutex.Lock()
+	udts[udtKey{keyspace, t}] = udt{name, naming}
+	udtsMutex.Unlock()
+}
+
+// columnDefinitions returns the column definitions, such as `"name" varchar`,
+// of the fields of a struct in struct order, for a table or type in keyspace.
+// Collections are frozen if nested is set, as they must be in user defined
+// types.
+func columnDefinitions(row interface{}, keyspace string, nested bool, naming NamingStrategy) ([]string, error) {
+	if err := r.Validate(row); err != nil {
+		return nil, err
+	}
+	m, _ := r.StructToMap(row)
+
+	definitions := []string{}
+	infos, _ := r.Fields(row)
+	for _, info := range infos {
+		value, ok := m[info.Key]
+		if !ok {
+			continue
+		}
+		typ := info.Type
+		if typ == "" {
+			var err error
+			if typ, err = typeOf(reflect.TypeOf(value), keyspace, nested, info.Tuple); err != nil {
+				return nil, err
+			}
+		}
+		definitions = append(definitions, fmt.Sprintf(`%q %v`, naming.ColumnName(info.Key), typ))
 	}
-	return cassaTypeToString(ct)
+	return definitions, nil
 }
 
 func cassaType(i interface{}) gocql.Type {
 	switch i.(type) {
+	case int8:
+		return gocql.TypeTinyInt
+	case int16:
+		return gocql.TypeSmallInt
 	case int, int32:
 		return gocql.TypeInt
 	case int64:
 		return gocql.TypeBigInt
+	case *big.Int:
+		return gocql.TypeVarint
+	case *inf.Dec:
+		return gocql.TypeDecimal
 	case string:
 		return gocql.TypeVarchar
+	case Ascii:
+		return gocql.TypeAscii
+	case Text:
+		return gocql.TypeText
 	case float32:
 		return gocql.TypeFloat
 	case float64:
@@ -57,8 +289,18 @@ func cassaType(i interface{}) gocql.Type {
 		return gocql.TypeBoolean
 	case time.Time:
 		return gocql.TypeTimestamp
+	case Date:
+		return gocql.TypeDate
+	case TimeOfDay:
+		return gocql.TypeTime
+	case time.Duration:
+		return gocql.TypeDuration
 	case gocql.UUID:
 		return gocql.TypeUUID
+	case TimeUUID:
+		return gocql.TypeTimeUUID
+	case net.IP:
+		return gocql.TypeInet
 	case []byte:
 		return gocql.TypeBlob
 	case Counter:
@@ -69,12 +311,24 @@ func cassaType(i interface{}) gocql.Type {
 
 func cassaTypeToString(t gocql.Type) (string, error) {
 	switch t {
+	case gocql.TypeTinyInt:
+		return "tinyint", nil
+	case gocql.TypeSmallInt:
+		return "smallint", nil
 	case gocql.TypeInt:
 		return "int", nil
 	case gocql.TypeBigInt:
 		return "bigint", nil
+	case gocql.TypeVarint:
+		return "varint", nil
+	case gocql.TypeDecimal:
+		return "decimal", nil
 	case gocql.TypeVarchar:
 		return "varchar", nil
+	case gocql.TypeAscii:
+		return "ascii", nil
+	case gocql.TypeText:
+		return "text", nil
 	case gocql.TypeFloat:
 		return "float", nil
 	case gocql.TypeDouble:
@@ -83,8 +337,18 @@ func cassaTypeToString(t gocql.Type) (string, error) {
 		return "boolean", nil
 	case gocql.TypeTimestamp:
 		return "timestamp", nil
+	case gocql.TypeDate:
+		return "date", nil
+	case gocql.TypeTime:
+		return "time", nil
+	case gocql.TypeDuration:
+		return "duration", nil
 	case gocql.TypeUUID:
 		return "uuid", nil
+	case gocql.TypeTimeUUID:
+		return "timeuuid", nil
+	case gocql.TypeInet:
+		return "inet", nil
 	case gocql.TypeBlob:
 		return "blob", nil
 	case gocql.TypeCounter:
@@ -0,0 +1,236 @@
+package gocqltable
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+type customType string
+
+func (t customType) Type() gocql.Type { return gocql.TypeCustom }
+func (t customType) Version() byte    { return 2 }
+func (t customType) Custom() string   { return marshalClassPrefix + string(t) }
+func (t customType) New() interface{} { return nil }
+
+func TestStringTypeOf(t *testing.T) {
+	var tests = []struct {
+		value interface{}
+		typ   string
+	}{
+		{int8(0), "tinyint"},
+		{int16(0), "smallint"},
+		{0, "int"},
+		{int64(0), "bigint"},
+		{big.NewInt(0), "varint"},
+		{inf.NewDec(0, 0), "decimal"},
+		{"", "varchar"},
+		{Ascii(""), "ascii"},
+		{Text(""), "text"},
+		{time.Time{}, "timestamp"},
+		{Date{}, "date"},
+		{TimeOfDay(0), "time"},
+		{time.Duration(0), "duration"},
+		{gocql.UUID{}, "uuid"},
+		{TimeUUID{}, "timeuuid"},
+		{net.IP{}, "inet"},
+		{[]byte{}, "blob"},
+		{[]int16{}, "list<smallint>"},
+		{map[string]Date{}, "map<varchar, date>"},
+		{map[int8]struct{}{}, "set<tinyint>"},
+		{Address{}, `frozen<"address">`},
+		{[]map[string]int{}, "list<frozen<map<varchar, int>>>"},
+		{map[string][]Address{}, `map<varchar, frozen<list<frozen<"address">>>>`},
+		{[2]float64{}, "frozen<tuple<double, double>>"},
+		{(*int8)(nil), "tinyint"},
+		{[]*Date{}, "list<date>"},
+		{(*Address)(nil), `frozen<"address">`},
+		{[][3]int8{}, "list<frozen<tuple<tinyint, tinyint, tinyint>>>"},
+	}
+	for _, test := range tests {
+		typ, err := stringTypeOf(test.value)
+		if err != nil {
+			t.Errorf("%T: %v", test.value, err)
+			continue
+		}
+		if typ != test.typ {
+			t.Errorf("%T: expected %s but got %s", test.value, test.typ, typ)
+		}
+	}
+
+	if _, err := stringTypeOf(struct{}{}); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+
+	typ, err := typeOf(reflect.TypeOf([]Point{}), "", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "list<frozen<tuple<int, int, varchar>>>"; typ != expected {
+		t.Errorf("expected %s but got %s", expected, typ)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	var tests = []struct {
+		info  customType
+		value interface{}
+		dest  interface{}
+	}{
+		{"ByteType", int8(-7), new(int8)},
+		{"ShortType", int16(-300), new(int16)},
+		{"SimpleDateType", NewDate(time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC)), new(Date)},
+		{"SimpleDateType", NewDate(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)), new(Date)},
+		{"TimeType", TimeOfDay(13*time.Hour + 37*time.Second), new(TimeOfDay)},
+		{"DurationType", -90 * time.Minute, new(time.Duration)},
+		{"DurationType", 1<<62 + time.Duration(1), new(time.Duration)},
+	}
+	for _, test := range tests {
+		data, err := marshal(test.info, test.value)
+		if err != nil {
+			t.Errorf("%s: marshal %v: %v", test.info, test.value, err)
+			continue
+		}
+		if err := unmarshal(test.info, data, test.dest); err != nil {
+			t.Errorf("%s: unmarshal %v: %v", test.info, test.value, err)
+			continue
+		}
+		switch dest := test.dest.(type) {
+		case *Date:
+			if !dest.Equal(test.value.(Date).Time) {
+				t.Errorf("%s: expected %v but got %v", test.info, test.value, *dest)
+			}
+		case *int8:
+			if *dest != test.value {
+				t.Errorf("%s: expected %v but got %v", test.info, test.value, *dest)
+			}
+		case *int16:
+			if *dest != test.value {
+				t.Errorf("%s: expected %v but got %v", test.info, test.value, *dest)
+			}
+		case *TimeOfDay:
+			if *dest != test.value {
+				t.Errorf("%s: expected %v but got %v", test.info, test.value, *dest)
+			}
+		case *time.Duration:
+			if *dest != test.value {
+				t.Errorf("%s: expected %v but got %v", test.info, test.value, *dest)
+			}
+		}
+	}
+
+	if _, err := marshal(customType("ByteType"), 300); err == nil {
+		t.Error("expected an error marshalling 300 into a tinyint")
+	}
+}
+
+func TestUnmarshalCollectionSize(t *testing.T) {
+	list := typeInfo{typ: gocql.TypeList, proto: 3, elem: typeInfo{typ: gocql.TypeInt, proto: 3}}
+	m := typeInfo{typ: gocql.TypeMap, proto: 3, key: typeInfo{typ: gocql.TypeInt, proto: 3}, elem: typeInfo{typ: gocql.TypeInt, proto: 3}}
+	oneInt := []byte{0, 0, 0, 4, 0, 0, 0, 1}
+	tests := []struct {
+		info gocql.TypeInfo
+		data []byte
+		dest interface{}
+	}{
+		{list, []byte{0x80, 0, 0, 0}, new([]int)},
+		{list, []byte{0xFF, 0xFF, 0xFF, 0xFF}, new([]int)},
+		{list, append([]byte{0, 0, 0, 2}, oneInt...), new([]int)},
+		{list, []byte{0x7F, 0xFF, 0xFF, 0xFF}, new(map[int]struct{})},
+		{m, []byte{0x80, 0, 0, 0}, new(map[int]int)},
+		{m, append([]byte{0, 0, 0, 1}, oneInt...), new(map[int]int)},
+		{typeInfo{typ: gocql.TypeList, proto: 2, elem: typeInfo{typ: gocql.TypeInt, proto: 2}}, []byte{0xFF, 0xFF}, new([]int)},
+	}
+	for _, test := range tests {
+		if err := unmarshal(test.info, test.data, test.dest); err == nil {
+			t.Errorf("expected an error unmarshalling %s from %x", test.info, test.data)
+		}
+	}
+
+	var dest []int
+	if err := unmarshal(list, append([]byte{0, 0, 0, 1}, oneInt...), &dest); err != nil || !reflect.DeepEqual(dest, []int{1}) {
+		t.Errorf("expected [1] but got %v, %v", dest, err)
+	}
+}
+
+func TestInetRoundTrip(t *testing.T) {
+	info := typeInfo{typ: gocql.TypeInet, proto: 3}
+	for _, ip := range []net.IP{net.IPv4(192, 168, 0, 1), net.ParseIP("2001:db8::1")} {
+		data, err := marshal(info, ip)
+		if err != nil {
+			t.Fatalf("marshal %v: %v", ip, err)
+		}
+		value := newValue(info)
+		if err := unmarshal(info, data, value); err != nil {
+			t.Fatalf("unmarshal %v: %v", ip, err)
+		}
+		decoded, ok := reflect.ValueOf(value).Elem().Interface().(net.IP)
+		if !ok || !decoded.Equal(ip) {
+			t.Errorf("expected inet to decode to net.IP %v but got %#v", ip, reflect.ValueOf(value).Elem().Interface())
+		}
+	}
+}
+
+func TestMarshalPointers(t *testing.T) {
+	info := customType("ShortType")
+	if data, err := marshal(info, (*int16)(nil)); err != nil || data != nil {
+		t.Errorf("expected a nil pointer to marshal to null but got %v, %v", data, err)
+	}
+
+	n := int16(42)
+	data, err := marshal(info, &n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p *int16
+	if err := unmarshal(info, data, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || *p != n {
+		t.Errorf("expected a pointer to %d but got %v", n, p)
+	}
+	if err := unmarshal(info, nil, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p != nil {
+		t.Errorf("expected null to unmarshal to a nil pointer but got %v", p)
+	}
+
+	var d *Date
+	if data, err := marshal(customType("SimpleDateType"), d); err != nil || data != nil {
+		t.Errorf("expected a nil *Date to marshal to null but got %v, %v", data, err)
+	}
+}
+
+func TestValidateRow(t *testing.T) {
+	type Duplicated struct {
+		Name  string
+		Other string `cql:"Name"`
+	}
+	type Unsupported struct {
+		Channel chan int
+	}
+	if err := ValidateRow(Address{}); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateRow("str"); !errors.Is(err, r.ErrNotAStruct) {
+		t.Errorf("expected ErrNotAStruct but got %v", err)
+	}
+	if err := ValidateRow(&Duplicated{}); !errors.Is(err, r.ErrDuplicateColumn) {
+		t.Errorf("expected ErrDuplicateColumn but got %v", err)
+	}
+	if err := ValidateRow(Unsupported{}); err == nil {
+		t.Error("expected an error for a field of an unsupported type")
+	}
+	if _, err := RowToMap(1); !errors.Is(err, r.ErrNotAStruct) {
+		t.Errorf("expected ErrNotAStruct but got %v", err)
+	}
+}
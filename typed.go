@@ -0,0 +1,94 @@
+package gocqltable
+
+import (
+	"context"
+	"iter"
+)
+
+// TypedTable is a Table of rows of type T, which must be a struct. Queries of
+// the table return *T rather than interface{}.
+type TypedTable[T any] struct {
+	Table
+}
+
+// NewTypedTable returns a TypedTable in the keyspace ks, see
+// Keyspace.NewTable.
+func NewTypedTable[T any](ks Keyspace, name string, rowKeys, rangeKeys []string) TypedTable[T] {
+	var row T
+	return TypedTable[T]{ks.NewTable(name, rowKeys, rangeKeys, row)}
+}
+
+func (t TypedTable[T]) Query(statement string, values ...interface{}) TypedQuery[T] {
+	return TypedQuery[T]{t.Table.Query(statement, values...)}
+}
+
+// TypedQuery is a Query of a TypedTable.
+type TypedQuery[T any] struct {
+	Query
+}
+
+func (q TypedQuery[T]) PageSize(n int) TypedQuery[T] {
+	return TypedQuery[T]{q.Query.PageSize(n)}
+}
+
+func (q TypedQuery[T]) PageState(state []byte) TypedQuery[T] {
+	return TypedQuery[T]{q.Query.PageState(state)}
+}
+
+func (q TypedQuery[T]) FetchRow() (*T, error) {
+	return typedRow[T](q.Query.FetchRow())
+}
+
+func (q TypedQuery[T]) FetchRowContext(ctx context.Context) (*T, error) {
+	return typedRow[T](q.Query.FetchRowContext(ctx))
+}
+
+func typedRow[T any](row interface{}, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return row.(*T), nil
+}
+
+func (q TypedQuery[T]) Fetch() *TypedIterator[T] {
+	return &TypedIterator[T]{q.Query.Fetch()}
+}
+
+func (q TypedQuery[T]) FetchContext(ctx context.Context) *TypedIterator[T] {
+	return &TypedIterator[T]{q.Query.FetchContext(ctx)}
+}
+
+// TypedIterator is an Iterator of rows of type T.
+type TypedIterator[T any] struct {
+	*Iterator
+}
+
+func (i *TypedIterator[T]) Next() *T {
+	row := i.Iterator.Next()
+	if row == nil {
+		return nil
+	}
+	return row.(*T)
+}
+
+func (i *TypedIterator[T]) All() iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for row, err := range i.Iterator.All() {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(row.(*T), nil) {
+				return
+			}
+		}
+	}
+}
+
+func (i *TypedIterator[T]) Range() <-chan *T {
+	return i.RangeContext(i.context())
+}
+
+func (i *TypedIterator[T]) RangeContext(ctx context.Context) <-chan *T {
+	return rangeRows(i.Iterator, ctx, func(row interface{}) *T { return row.(*T) })
+}
@@ -0,0 +1,59 @@
+package gocqltable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypedTable(t *testing.T) {
+	ks := Keyspace{name: "ks", naming: SnakeCase}
+	table := NewTypedTable[Address](ks, "addresses", []string{"Zip"}, nil)
+	if _, ok := table.Row().(Address); !ok {
+		t.Errorf("expected rows of type Address but got %T", table.Row())
+	}
+	if table.NamingStrategy() != SnakeCase {
+		t.Error("expected the table to use the naming strategy of its keyspace")
+	}
+
+	q := table.Query("SELECT * FROM addresses")
+	if _, ok := q.Table.Row().(Address); !ok {
+		t.Errorf("expected queries of rows of type Address but got %T", q.Table.Row())
+	}
+}
+
+func TestTypedIterator(t *testing.T) {
+	_, events := memoryEventsTable(t)
+	table := NewTypedTable[memoryEvent](events.Keyspace(), "events", []string{"Stream"}, []string{"Seq"})
+	query := table.Query(`SELECT * FROM "events"."events" WHERE "stream" = ?`, "a")
+
+	event, err := query.FetchRow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Stream != "a" || event.Seq != 3 {
+		t.Errorf("expected the last event of stream a but got %+v", event)
+	}
+
+	var seqs []int
+	for event, err := range query.Fetch().All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqs = append(seqs, event.Seq)
+	}
+	if expected := []int{3, 2, 1}; !reflect.DeepEqual(seqs, expected) {
+		t.Errorf("expected %v but got %v", expected, seqs)
+	}
+
+	seqs = nil
+	iter := query.Fetch()
+	for event := range iter.Range() {
+		seqs = append(seqs, event.Seq)
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{3, 2, 1}; !reflect.DeepEqual(seqs, expected) {
+		t.Errorf("expected %v but got %v", expected, seqs)
+	}
+}
@@ -0,0 +1,386 @@
+package gocqltable
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+// typeInfo describes the parameterized types for the codec. It is built from
+// gocql's collection, tuple and user defined types, and from marshal class
+// names. gocql only turns the class names of scalar types into types, so the
+// class names of the parameterized types that servers send as custom types,
+// that the schema tables of Cassandra 2 hold and that recordings keep are
+// parsed by parseClass.
+type typeInfo struct {
+	typ    gocql.Type
+	proto  byte
+	custom string
+
+	// keyspace and name of a user defined type
+	keyspace string
+	name     string
+
+	key    gocql.TypeInfo   // map key
+	elem   gocql.TypeInfo   // list, set and map element
+	elems  []gocql.TypeInfo // tuple elements and user defined type fields
+	fields []string         // user defined type field names
+}
+
+func (t typeInfo) Type() gocql.Type {
+	return t.typ
+}
+
+func (t typeInfo) Version() byte {
+	return t.proto
+}
+
+func (t typeInfo) Custom() string {
+	return t.custom
+}
+
+func (t typeInfo) New() interface{} {
+	return newValue(t)
+}
+
+func (t typeInfo) String() string {
+	switch t.typ {
+	case gocql.TypeList:
+		return fmt.Sprintf("list<%s>", typeName(t.elem))
+	case gocql.TypeSet:
+		return fmt.Sprintf("set<%s>", typeName(t.elem))
+	case gocql.TypeMap:
+		return fmt.Sprintf("map<%s, %s>", typeName(t.key), typeName(t.elem))
+	case gocql.TypeUDT:
+		return t.name
+	case gocql.TypeCustom:
+		return fmt.Sprintf("custom(%s)", t.custom)
+	}
+	if name, err := cassaTypeToString(t.typ); err == nil {
+		return name
+	}
+	return t.typ.String()
+}
+
+// resolveType returns a description of info the codec understands: the
+// parameterized types are turned into typeInfo and custom types are parsed
+// from their marshal class name.
+func resolveType(info gocql.TypeInfo) gocql.TypeInfo {
+	switch info := info.(type) {
+	case nil, typeInfo:
+		return info
+	case gocql.CollectionType:
+		t := typeInfo{
+			typ:   info.Type(),
+			proto: info.Version(),
+			elem:  resolveNested(info.Elem),
+		}
+		if info.Key != nil {
+			t.key = resolveNested(info.Key)
+		}
+		return t
+	case gocql.TupleTypeInfo:
+		t := typeInfo{
+			typ:   info.Type(),
+			proto: info.Version(),
+		}
+		for _, elem := range info.Elems {
+			t.elems = append(t.elems, resolveNested(elem))
+		}
+		return t
+	case gocql.UDTTypeInfo:
+		t := typeInfo{
+			typ:      info.Type(),
+			proto:    info.Version(),
+			keyspace: info.KeySpace,
+			name:     info.Name,
+		}
+		for _, field := range info.Elements {
+			t.fields = append(t.fields, field.Name)
+			t.elems = append(t.elems, resolveNested(field.Type))
+		}
+		return t
+	}
+	// gocql keeps the marshal class of custom types, also of those it gives
+	// a type to but not its parameters, such as lists
+	if info.Custom() != "" {
+		return parseClass(info.Custom(), info.Version())
+	}
+	return info
+}
+
+// resolveNested resolves the type of a value nested in a collection, tuple or
+// user defined type. Nested collections are always encoded like protocol
+// version 3 collections.
+func resolveNested(info gocql.TypeInfo) gocql.TypeInfo {
+	info = resolveType(info)
+	if t, ok := info.(typeInfo); ok && t.proto < 3 {
+		t.proto = 3
+		return t
+	}
+	return info
+}
+
+var parsedClassesMutex sync.RWMutex
+var parsedClasses = make(map[string]typeInfo)
+
+// parseClass parses a marshal class name such as
+// "org.apache.cassandra.db.marshal.ListType(org.apache.cassandra.db.marshal.Int32Type)".
+func parseClass(class string, proto byte) typeInfo {
+	key := fmt.Sprintf("%d:%s", proto, class)
+	parsedClassesMutex.RLock()
+	t, found := parsedClasses[key]
+	parsedClassesMutex.RUnlock()
+	if found {
+		return t
+	}
+
+	p := classParser{s: class, proto: proto}
+	t = p.parse()
+	if p.err != nil || p.s != "" {
+		t = typeInfo{typ: gocql.TypeCustom, proto: proto, custom: class}
+	}
+
+	parsedClassesMutex.Lock()
+	parsedClasses[key] = t
+	parsedClassesMutex.Unlock()
+	return t
+}
+
+// marshalClasses are the marshal class names of the scalar types, without
+// their package.
+var marshalClasses = map[gocql.Type]string{
+	gocql.TypeAscii:     "AsciiType",
+	gocql.TypeBigInt:    "LongType",
+	gocql.TypeBlob:      "BytesType",
+	gocql.TypeBoolean:   "BooleanType",
+	gocql.TypeCounter:   "CounterColumnType",
+	gocql.TypeDecimal:   "DecimalType",
+	gocql.TypeDouble:    "DoubleType",
+	gocql.TypeFloat:     "FloatType",
+	gocql.TypeInt:       "Int32Type",
+	gocql.TypeTimestamp: "TimestampType",
+	gocql.TypeUUID:      "UUIDType",
+	gocql.TypeVarchar:   "UTF8Type",
+	gocql.TypeVarint:    "IntegerType",
+	gocql.TypeTimeUUID:  "TimeUUIDType",
+	gocql.TypeInet:      "InetAddressType",
+	gocql.TypeText:      "UTF8Type",
+	gocql.TypeTinyInt:   "ByteType",
+	gocql.TypeSmallInt:  "ShortType",
+	gocql.TypeDate:      "SimpleDateType",
+	gocql.TypeTime:      "TimeType",
+	gocql.TypeDuration:  "DurationType",
+}
+
+// marshalClass returns the marshal class name of a type, which parseClass
+// parses back into it.
+func marshalClass(info gocql.TypeInfo) string {
+	info = resolveType(info)
+	t, _ := info.(typeInfo)
+	switch info.Type() {
+	case gocql.TypeCustom:
+		return info.Custom()
+	case gocql.TypeList:
+		return marshalClassPrefix + "ListType(" + marshalClass(t.elem) + ")"
+	case gocql.TypeSet:
+		return marshalClassPrefix + "SetType(" + marshalClass(t.elem) + ")"
+	case gocql.TypeMap:
+		return marshalClassPrefix + "MapType(" + marshalClass(t.key) + "," + marshalClass(t.elem) + ")"
+	case gocql.TypeTuple:
+		elems := make([]string, len(t.elems))
+		for i, elem := range t.elems {
+			elems[i] = marshalClass(elem)
+		}
+		return marshalClassPrefix + "TupleType(" + strings.Join(elems, ",") + ")"
+	case gocql.TypeUDT:
+		params := []string{t.keyspace, hex.EncodeToString([]byte(t.name))}
+		for i, field := range t.fields {
+			params = append(params, hex.EncodeToString([]byte(field))+":"+marshalClass(t.elems[i]))
+		}
+		return marshalClassPrefix + "UserType(" + strings.Join(params, ",") + ")"
+	}
+	return marshalClassPrefix + marshalClasses[info.Type()]
+}
+
+type classParser struct {
+	s     string
+	proto byte
+	err   error
+}
+
+func (p *classParser) parse() typeInfo {
+	name := p.name()
+	t := typeInfo{proto: p.proto, custom: name}
+	if !strings.Contains(name, ".") {
+		t.custom = marshalClassPrefix + name
+	}
+	switch name {
+	case "AsciiType":
+		t.typ = gocql.TypeAscii
+	case "LongType":
+		t.typ = gocql.TypeBigInt
+	case "BytesType":
+		t.typ = gocql.TypeBlob
+	case "BooleanType":
+		t.typ = gocql.TypeBoolean
+	case "CounterColumnType":
+		t.typ = gocql.TypeCounter
+	case "DecimalType":
+		t.typ = gocql.TypeDecimal
+	case "DoubleType":
+		t.typ = gocql.TypeDouble
+	case "FloatType":
+		t.typ = gocql.TypeFloat
+	case "Int32Type":
+		t.typ = gocql.TypeInt
+	case "DateType", "TimestampType":
+		t.typ = gocql.TypeTimestamp
+	case "UUIDType":
+		t.typ = gocql.TypeUUID
+	case "UTF8Type":
+		t.typ = gocql.TypeVarchar
+	case "IntegerType":
+		t.typ = gocql.TypeVarint
+	case "TimeUUIDType":
+		t.typ = gocql.TypeTimeUUID
+	case "InetAddressType":
+		t.typ = gocql.TypeInet
+	case "ByteType":
+		t.typ = gocql.TypeTinyInt
+	case "ShortType":
+		t.typ = gocql.TypeSmallInt
+	case "SimpleDateType":
+		t.typ = gocql.TypeDate
+	case "TimeType":
+		t.typ = gocql.TypeTime
+	case "DurationType":
+		t.typ = gocql.TypeDuration
+	case "ReversedType", "FrozenType":
+		params := p.params()
+		if len(params) != 1 {
+			p.fail(name)
+			return t
+		}
+		return p.sub(params[0], p.proto)
+	case "ListType", "SetType":
+		t.typ = gocql.TypeList
+		if name == "SetType" {
+			t.typ = gocql.TypeSet
+		}
+		params := p.params()
+		if len(params) != 1 {
+			p.fail(name)
+			return t
+		}
+		t.elem = p.sub(params[0], 3)
+	case "MapType":
+		t.typ = gocql.TypeMap
+		params := p.params()
+		if len(params) != 2 {
+			p.fail(name)
+			return t
+		}
+		t.key = p.sub(params[0], 3)
+		t.elem = p.sub(params[1], 3)
+	case "TupleType":
+		t.typ = gocql.TypeTuple
+		for _, param := range p.params() {
+			t.elems = append(t.elems, p.sub(param, 3))
+		}
+	case "UserType":
+		t.typ = gocql.TypeUDT
+		params := p.params()
+		if len(params) < 2 {
+			p.fail(name)
+			return t
+		}
+		t.keyspace = params[0]
+		t.name = p.hex(params[1])
+		for _, param := range params[2:] {
+			i := strings.Index(param, ":")
+			if i < 0 {
+				p.fail(name)
+				return t
+			}
+			t.fields = append(t.fields, p.hex(param[:i]))
+			t.elems = append(t.elems, p.sub(param[i+1:], 3))
+		}
+	default:
+		t.typ = gocql.TypeCustom
+		if strings.HasPrefix(p.s, "(") {
+			p.params()
+		}
+	}
+	return t
+}
+
+// name consumes a class name without its package.
+func (p *classParser) name() string {
+	i := strings.IndexAny(p.s, "(),")
+	if i < 0 {
+		i = len(p.s)
+	}
+	name := strings.TrimSpace(p.s[:i])
+	p.s = p.s[i:]
+	return strings.TrimPrefix(name, marshalClassPrefix)
+}
+
+// params consumes a parenthesized, comma separated parameter list.
+func (p *classParser) params() []string {
+	if !strings.HasPrefix(p.s, "(") {
+		return nil
+	}
+	var params []string
+	depth, start := 0, 1
+	for i, c := range p.s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				if param := strings.TrimSpace(p.s[start:i]); param != "" || len(params) > 0 {
+					params = append(params, param)
+				}
+				p.s = p.s[i+1:]
+				return params
+			}
+		case ',':
+			if depth == 1 {
+				params = append(params, strings.TrimSpace(p.s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	p.fail("unbalanced parentheses")
+	return nil
+}
+
+func (p *classParser) sub(class string, proto byte) typeInfo {
+	sub := classParser{s: class, proto: proto}
+	t := sub.parse()
+	if sub.err != nil {
+		p.err = sub.err
+	} else if sub.s != "" {
+		p.fail(class)
+	}
+	return t
+}
+
+func (p *classParser) hex(s string) string {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		p.err = err
+	}
+	return string(b)
+}
+
+func (p *classParser) fail(what string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("can not parse marshal class %s", what)
+	}
+}
@@ -0,0 +1,191 @@
+package gocqltable
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func TestParseClass(t *testing.T) {
+	udt := marshalClassPrefix + "UserType(ks," + hex.EncodeToString([]byte("address")) + "," +
+		hex.EncodeToString([]byte("street")) + ":" + marshalClassPrefix + "UTF8Type," +
+		hex.EncodeToString([]byte("zip")) + ":" + marshalClassPrefix + "Int32Type)"
+	var tests = []struct {
+		class string
+		typ   string
+	}{
+		{marshalClassPrefix + "ByteType", "tinyint"},
+		{marshalClassPrefix + "ListType(" + marshalClassPrefix + "ShortType)", "list<smallint>"},
+		{marshalClassPrefix + "MapType(" + marshalClassPrefix + "UTF8Type," + marshalClassPrefix + "SimpleDateType)", "map<varchar, date>"},
+		{marshalClassPrefix + "ReversedType(" + marshalClassPrefix + "TimeUUIDType)", "timeuuid"},
+		{marshalClassPrefix + "FrozenType(" + udt + ")", "address"},
+		{"com.example.MyType", "custom(com.example.MyType)"},
+		{marshalClassPrefix + "ListType(", "custom(" + marshalClassPrefix + "ListType()"},
+	}
+	for _, test := range tests {
+		if typ := parseClass(test.class, 2).String(); typ != test.typ {
+			t.Errorf("%s: expected %s but got %s", test.class, test.typ, typ)
+		}
+	}
+
+	info := parseClass(udt, 2)
+	if info.keyspace != "ks" || len(info.fields) != 2 || info.fields[0] != "street" || info.fields[1] != "zip" {
+		t.Errorf("unexpected user defined type %+v", info)
+	}
+	if class := marshalClass(info); class != udt {
+		t.Errorf("expected marshal class %s but got %s", udt, class)
+	}
+	list := marshalClassPrefix + "ListType(" + marshalClassPrefix + "ShortType)"
+	if class := marshalClass(parseClass(list, 2)); class != list {
+		t.Errorf("expected marshal class %s but got %s", list, class)
+	}
+}
+
+func TestResolveType(t *testing.T) {
+	udt := gocql.UDTTypeInfo{
+		NativeType: gocql.NewNativeType(3, gocql.TypeUDT, ""),
+		KeySpace:   "ks",
+		Name:       "address",
+		Elements: []gocql.UDTField{
+			{Name: "street", Type: gocql.NewNativeType(3, gocql.TypeVarchar, "")},
+			{Name: "zip", Type: gocql.NewNativeType(3, gocql.TypeInt, "")},
+		},
+	}
+	info, ok := resolveType(udt).(typeInfo)
+	if !ok || info.keyspace != "ks" || info.name != "address" || len(info.elems) != 2 || info.fields[1] != "zip" {
+		t.Errorf("unexpected user defined type %+v", resolveType(udt))
+	}
+
+	// gocql types lists of custom types by their class, without elements
+	list := gocql.NewNativeType(2, gocql.TypeList, marshalClassPrefix+"ListType("+marshalClassPrefix+"SimpleDateType)")
+	if typ := resolveType(list).(typeInfo).String(); typ != "list<date>" {
+		t.Errorf("expected list<date> but got %s", typ)
+	}
+}
+
+type Address struct {
+	Street string
+	Zip    int `cql:"zip"`
+	Tags   map[string]struct{}
+}
+
+func TestMarshalUDT(t *testing.T) {
+	info := typeInfo{
+		typ:    gocql.TypeUDT,
+		proto:  3,
+		name:   "address",
+		fields: []string{"street", "zip", "tags", "added"},
+		elems: []gocql.TypeInfo{
+			typeInfo{typ: gocql.TypeVarchar, proto: 3},
+			typeInfo{typ: gocql.TypeInt, proto: 3},
+			typeInfo{typ: gocql.TypeSet, proto: 3, elem: typeInfo{typ: gocql.TypeVarchar, proto: 3}},
+			typeInfo{typ: gocql.TypeBoolean, proto: 3},
+		},
+	}
+	address := Address{"Main St", 1234, map[string]struct{}{"home": {}}}
+	data, err := marshal(info, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Address
+	if err := unmarshal(info, data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Street != address.Street || decoded.Zip != address.Zip || len(decoded.Tags) != 1 {
+		t.Errorf("expected %v but got %v", address, decoded)
+	}
+
+	m := map[string]interface{}{}
+	if err := unmarshal(info, data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["street"] != "Main St" || m["zip"] != 1234 || m["added"] != false {
+		t.Errorf("unexpected map %v", m)
+	}
+}
+
+type Point struct {
+	X, Y int
+	Name string
+}
+
+func TestMarshalTuple(t *testing.T) {
+	info := typeInfo{
+		typ:   gocql.TypeTuple,
+		proto: 3,
+		elems: []gocql.TypeInfo{
+			typeInfo{typ: gocql.TypeInt, proto: 3},
+			typeInfo{typ: gocql.TypeInt, proto: 3},
+			typeInfo{typ: gocql.TypeVarchar, proto: 3},
+		},
+	}
+	point := Point{1, -2, "p"}
+	data, err := marshal(info, point)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Point
+	if err := unmarshal(info, data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != point {
+		t.Errorf("expected %v but got %v", point, decoded)
+	}
+
+	var elems []interface{}
+	if err := unmarshal(info, data, &elems); err != nil {
+		t.Fatal(err)
+	}
+	if len(elems) != 3 || elems[0] != 1 || elems[1] != -2 || elems[2] != "p" {
+		t.Errorf("unexpected elements %v", elems)
+	}
+
+	if _, err := marshal(info, [2]int{1, 2}); err == nil {
+		t.Error("expected an error marshalling 2 elements into a tuple of 3")
+	}
+}
+
+type udtPlace struct {
+	Name string
+}
+
+type udtVisit struct {
+	Visitor string
+	Place   udtPlace
+}
+
+func TestCreateTypePerKeyspace(t *testing.T) {
+	s := NewMemorySession()
+	for _, name := range []string{"north", "south"} {
+		ks := NewKeyspace(name)
+		ks.SetBackend(s)
+		if err := ks.CreateWithOptions(KeyspaceOptions{Replication: SimpleStrategy{1}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ks.CreateType(name+"_place", udtPlace{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := NewKeyspace("missing")
+	missing.SetBackend(s)
+	if err := missing.CreateType("missing_place", udtPlace{}); err == nil {
+		t.Fatal("expected creating a type in a missing keyspace to fail")
+	}
+
+	for keyspace, expected := range map[string]string{
+		"north":   `"place" frozen<"north_place">`,
+		"south":   `"place" frozen<"south_place">`,
+		"missing": `"place" frozen<"udtplace">`,
+	} {
+		definitions, err := columnDefinitions(udtVisit{}, keyspace, false, LowerCase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if definitions[1] != expected {
+			t.Errorf("%s: expected %s but got %s", keyspace, expected, definitions[1])
+		}
+	}
+}